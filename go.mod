@@ -1,5 +1,7 @@
 module github.com/ardielle/ardielle-tools
 
+go 1.18
+
 require (
 	github.com/ardielle/ardielle-go v1.5.2
 	github.com/jawher/mow.cli v1.0.4
@@ -0,0 +1,425 @@
+package importer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/ardielle/ardielle-tools/rdl-plugins/swagger"
+)
+
+// DefaultMaxDepth is the nesting depth of schemas beyond which conversion
+// gives up, unless Options.MaxDepth says otherwise.
+const DefaultMaxDepth = 64
+
+// Options controls how swagger documents are converted to RDL.
+type Options struct {
+	// Workers bounds the number of conversions ConvertBatch runs at once.
+	// Zero means one per CPU.
+	Workers int
+
+	// EmptyObject selects how an object schema with neither properties nor
+	// additionalProperties is imported: "struct" (the default) for an empty
+	// Struct, "any" for an alias of Any, or "map" for a Map<String,Any>.
+	EmptyObject string
+
+	// JSONSchema reads the document as a standalone JSON Schema, importing its
+	// definitions, or $defs, and the type it describes, named after its title.
+	JSONSchema bool
+
+	// KeepName uses the name passed to Convert for the schema even when the
+	// document's title has the form "The X API", which otherwise names it X.
+	KeepName bool
+
+	// Stamp records the SHA-256 of the input and the importer version as
+	// x_source_sha256 and x_generator_version schema annotations.
+	Stamp bool
+
+	// Version is the importer version Stamp records, such as the version the
+	// command line was built with.
+	Version string
+
+	// NoExamples suppresses the x_example annotations normally carried over
+	// from swagger examples.
+	NoExamples bool
+
+	// ValidateExamples checks each imported example against the RDL type it
+	// illustrates, and warns about those that do not match.
+	ValidateExamples bool
+
+	// DefaultInt is the type of integers with no format: "int32" (the
+	// default) or "int64".
+	DefaultInt string
+
+	// FieldCase selects how fields are named: "preserve" (the default) for the
+	// swagger property names as they are, or "camel" or "snake" to rename them
+	// consistently. A renamed field keeps its wire name in x_json_name.
+	FieldCase string
+
+	// Acronyms are the words, such as ID or URL, rendered as given rather than
+	// capitalized when the importer makes up a name from several words, as it
+	// does for inline types, operations without an operationId, and fields
+	// renamed by FieldCase. Each is matched regardless of case.
+	Acronyms []string
+
+	// MaxDepth bounds how deeply schemas may nest before conversion fails.
+	// Zero means DefaultMaxDepth.
+	MaxDepth int
+
+	// Annotations are added to the schema, e.g. to give generators a hint such
+	// as x_go_package. Each name must start with x_.
+	Annotations map[string]string
+
+	// TypePrefix is prepended to the name of every type the schema defines,
+	// to avoid collisions when schemas are combined.
+	TypePrefix string
+
+	// Unwrap imports a wrapper object, one whose only property is an array,
+	// as that array, wherever it is used. See unwrapTypes.
+	Unwrap bool
+
+	// InlineSingleUse folds the types made up for struct fields back into the
+	// fields that use them, where RDL can write them inline. See
+	// inlineSingleUse.
+	InlineSingleUse bool
+
+	// FlattenPaths names the resources without an operationId, and the types
+	// made up for them, after their paths with the templated segments
+	// stripped, e.g. getUsersOrders for GET /users/{id}/orders/{orderId}. The
+	// paths themselves are unchanged. It is experimental, for generators that
+	// cannot handle names made from templated paths. See flatPath.
+	FlattenPaths bool
+
+	// DependencyOrder orders the types so that each follows the types it
+	// refers to, rather than just its supertype.
+	DependencyOrder bool
+
+	// SummaryAsName names the resources for operations without an operationId
+	// after their summaries, when short enough, rather than their paths.
+	SummaryAsName bool
+
+	// ResourceError defines the standard RDL ResourceError type, with a code
+	// and a message, when the schema uses it without defining it, and uses it
+	// for error responses that have no schema of their own.
+	ResourceError bool
+
+	// SplitReadOnly imports each definition with readOnly or writeOnly
+	// properties as two types: one without the writeOnly properties, for
+	// responses, and a request variant without the readOnly ones, for bodies.
+	SplitReadOnly bool
+
+	// FailOnUnsupportedType makes a schema of a type that cannot be imported
+	// an error, rather than a warning and a missing type.
+	FailOnUnsupportedType bool
+
+	// VerboseComments lists the descriptions of a resource's parameters in
+	// its comment, as well as giving them to the inputs.
+	VerboseComments bool
+
+	// OnlyTags limits the import to the operations with at least one of these
+	// tags, and ExcludeTags leaves out those with any of these. An operation
+	// with tags in both is left out.
+	OnlyTags    []string
+	ExcludeTags []string
+
+	// KeepUnused keeps the types no imported operation refers to, which are
+	// otherwise pruned when operations are filtered by tag.
+	KeepUnused bool
+
+	// NoResources imports the definitions alone, as a library of types,
+	// ignoring the paths. Every definition is still imported, including those
+	// only the operations use, and none are pruned by the tag filters.
+	NoResources bool
+}
+
+// selected returns true if the operation passes the tag filters.
+func (opts Options) selected(op *swagger.Operation) bool {
+	for _, tag := range op.Tags {
+		for _, t := range opts.ExcludeTags {
+			if tag == t {
+				return false
+			}
+		}
+	}
+	if len(opts.OnlyTags) == 0 {
+		return true
+	}
+	for _, tag := range op.Tags {
+		for _, t := range opts.OnlyTags {
+			if tag == t {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Feature is a swagger construct that was dropped, or only approximated, when
+// converting to RDL.
+type Feature struct {
+	Construct string `json:"construct"`
+	Location  string `json:"location"`
+	Reason    string `json:"reason"`
+
+	// Status is "dropped" or "approximated".
+	Status string `json:"status"`
+}
+
+// Coverage tallies the constructs met in a conversion, the definitions,
+// properties, operations, parameters, and responses, by how faithfully they
+// were imported. A construct is dropped or approximated if a Feature at or
+// below its location says so, and dropped if both.
+type Coverage struct {
+	Constructs   int `json:"constructs"`
+	Faithful     int `json:"faithful"`
+	Approximated int `json:"approximated"`
+	Dropped      int `json:"dropped"`
+}
+
+// coverage attributes each feature to the innermost construct containing its
+// location, and tallies the constructs.
+func coverage(constructs []string, features []Feature) Coverage {
+	status := make(map[string]string)
+	for _, f := range features {
+		owner := ""
+		for _, loc := range constructs {
+			if (f.Location == loc || strings.HasPrefix(f.Location, loc+".")) && len(loc) > len(owner) {
+				owner = loc
+			}
+		}
+		if owner != "" && status[owner] != "dropped" {
+			status[owner] = f.Status
+		}
+	}
+	c := Coverage{Constructs: len(constructs)}
+	for _, loc := range constructs {
+		switch status[loc] {
+		case "dropped":
+			c.Dropped++
+		case "approximated":
+			c.Approximated++
+		default:
+			c.Faithful++
+		}
+	}
+	return c
+}
+
+func (c Coverage) String() string {
+	percent := func(n int) float64 {
+		if c.Constructs == 0 {
+			return 100
+		}
+		return 100 * float64(n) / float64(c.Constructs)
+	}
+	return fmt.Sprintf("coverage: %d constructs, %d (%.1f%%) imported faithfully, %d (%.1f%%) approximated, %d (%.1f%%) dropped",
+		c.Constructs, c.Faithful, percent(c.Faithful), c.Approximated, percent(c.Approximated), c.Dropped, percent(c.Dropped))
+}
+
+// Warning is a problem with the input that did not stop the conversion, at
+// a location such as definitions.Pet.name.
+type Warning struct {
+	Location string `json:"location,omitempty"`
+	Message  string `json:"message"`
+}
+
+// warnings collects the warnings of a conversion as they are reported. They
+// are returned to the caller, which decides how to show them.
+type warnings []Warning
+
+func (w *warnings) add(location string, format string, args ...interface{}) {
+	*w = append(*w, Warning{Location: location, Message: fmt.Sprintf(format, args...)})
+}
+
+// Report is what a conversion found out along the way: the warnings given,
+// the constructs dropped or approximated, and the coverage of the constructs
+// met, as the command line reports them.
+type Report struct {
+	Warnings []Warning `json:"warnings"`
+	Features []Feature `json:"features"`
+	Coverage Coverage  `json:"coverage"`
+}
+
+// Input is a named swagger document to be converted by ConvertBatch.
+type Input struct {
+	Name string
+	Data []byte
+}
+
+// Result is the outcome of converting a single Input. Exactly one of
+// Schema and Err is set. Warnings are those the conversion gave, including
+// those before it failed.
+type Result struct {
+	Name     string
+	Schema   *rdl.Schema
+	Warnings []Warning
+	Err      error
+}
+
+// Convert parses the swagger JSON in data, or a Postman collection, and returns
+// the equivalent RDL schema.
+// The name is used for the schema unless the document's title overrides it;
+// see Options.KeepName.
+func Convert(name string, data []byte, opts Options) (*rdl.Schema, error) {
+	schema, _, err := ConvertWithReport(name, data, opts)
+	return schema, err
+}
+
+// ConvertWithReport is Convert, also returning a report of the conversion,
+// with the warnings that Convert leaves out. If the conversion fails part way,
+// the report is still returned with the error, holding the warnings given
+// before it failed.
+func ConvertWithReport(name string, data []byte, opts Options) (*rdl.Schema, *Report, error) {
+	switch opts.EmptyObject {
+	case "", "struct", "any", "map":
+	default:
+		return nil, nil, fmt.Errorf("bad empty object mode: %q", opts.EmptyObject)
+	}
+	switch opts.DefaultInt {
+	case "", "int32", "int64":
+	default:
+		return nil, nil, fmt.Errorf("bad default int type: %q", opts.DefaultInt)
+	}
+	switch opts.FieldCase {
+	case "", "preserve", "camel", "snake":
+	default:
+		return nil, nil, fmt.Errorf("bad field case: %q", opts.FieldCase)
+	}
+	for _, a := range opts.Acronyms {
+		if !isIdentifier(a) {
+			return nil, nil, fmt.Errorf("bad acronym: %q", a)
+		}
+	}
+	if opts.TypePrefix != "" && !isIdentifier(opts.TypePrefix) {
+		return nil, nil, fmt.Errorf("bad type prefix: %q", opts.TypePrefix)
+	}
+	for k := range opts.Annotations {
+		if !strings.HasPrefix(k, "x_") || !isIdentifier(k) {
+			return nil, nil, fmt.Errorf("bad annotation name: %q (must be an identifier starting with x_)", k)
+		}
+	}
+	var doc *swagger.Doc
+	var warned warnings
+	var err error
+	if opts.JSONSchema {
+		doc, err = jsonSchemaToSwagger(name, data)
+	} else if isPostmanCollection(data) {
+		doc, warned, err = postmanToSwagger(name, data)
+	} else {
+		err = json.Unmarshal(data, &doc)
+	}
+	if err != nil {
+		if !looksLikeJSON(data) {
+			return nil, nil, fmt.Errorf("%s: not a JSON document (YAML is not supported, convert it to JSON first)", name)
+		}
+		return nil, nil, err
+	}
+	if doc == nil {
+		return nil, nil, fmt.Errorf("%s: not a swagger document", name)
+	}
+	schema, rep, err := swaggerToSchema(name, doc, opts)
+	rep.Warnings = append(warned, rep.Warnings...)
+	if err != nil {
+		return nil, rep, err
+	}
+	for k, v := range infoExtensions(data) {
+		schema.Annotations = addAnnotation(schema.Annotations, k, v)
+	}
+	if opts.Stamp {
+		if schema.Annotations == nil {
+			schema.Annotations = make(map[rdl.ExtendedAnnotation]string)
+		}
+		sum := sha256.Sum256(data)
+		schema.Annotations["x_source_sha256"] = hex.EncodeToString(sum[:])
+		schema.Annotations["x_generator_version"] = opts.Version
+	}
+	for k, v := range opts.Annotations {
+		schema.Annotations = addAnnotation(schema.Annotations, k, v)
+	}
+	return schema, rep, nil
+}
+
+// infoExtensions returns the x- keys of the document's info, such as x-logo, as
+// annotation names, with x- becoming x_ and any other dashes underscores, and
+// their values. The swagger model has no place for them, so they are read
+// from the raw document.
+func infoExtensions(data []byte) map[string]interface{} {
+	var probe struct {
+		Info map[string]interface{} `json:"info"`
+	}
+	if json.Unmarshal(data, &probe) != nil {
+		return nil
+	}
+	extensions := make(map[string]interface{})
+	for k, v := range probe.Info {
+		if name := strings.Replace(k, "-", "_", -1); strings.HasPrefix(k, "x-") && isIdentifier(name) {
+			extensions[name] = v
+		}
+	}
+	return extensions
+}
+
+// looksLikeJSON returns true if the data looks like a JSON object or array
+// rather than YAML, judging by its first non-blank character.
+func looksLikeJSON(data []byte) bool {
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	return len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[')
+}
+
+// ConvertBatch converts the inputs concurrently, using at most opts.Workers
+// goroutines. Each conversion is independent, so one failure does not abort
+// the others: the results are in the same order as the inputs and carry
+// their own errors. The returned error is non-nil if any conversion failed.
+func ConvertBatch(inputs []Input, opts Options) ([]Result, error) {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(inputs) {
+		workers = len(inputs)
+	}
+	results := make([]Result, len(inputs))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = convertInput(inputs[i], opts)
+			}
+		}()
+	}
+	for i := range inputs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return results, fmt.Errorf("%d of %d conversions failed", failed, len(inputs))
+	}
+	return results, nil
+}
+
+// convertInput converts a single batch input.
+func convertInput(in Input, opts Options) Result {
+	res := Result{Name: in.Name}
+	schema, rep, err := ConvertWithReport(in.Name, in.Data, opts)
+	if rep != nil {
+		res.Warnings = rep.Warnings
+	}
+	res.Schema, res.Err = schema, err
+	return res
+}
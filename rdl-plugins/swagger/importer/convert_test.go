@@ -0,0 +1,205 @@
+package importer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+// TestConvertBatch checks that the results of a batch come back in the order
+// of the inputs, whatever the number of workers, with each failure confined
+// to its own result and the warnings of each conversion in its result.
+func TestConvertBatch(t *testing.T) {
+	var inputs []Input
+	for i := 0; i < 10; i++ {
+		doc := swaggerDoc(`{}`, fmt.Sprintf(`{"T%d": {"type": "string"}}`, i))
+		switch i {
+		case 3:
+			doc = `{"swagger": `
+		case 5:
+			doc = swaggerDoc(`{}`, `{"T5": {"type": "array"}}`)
+		case 7:
+			doc = "swagger: '2.0'\n"
+		}
+		inputs = append(inputs, Input{Name: fmt.Sprintf("s%d", i), Data: []byte(doc)})
+	}
+	for _, workers := range []int{0, 1, 3, 20} {
+		results, err := ConvertBatch(inputs, Options{Workers: workers})
+		if err == nil || err.Error() != "2 of 10 conversions failed" {
+			t.Errorf("workers %d: error %v, want 2 of 10 conversions failed", workers, err)
+		}
+		if len(results) != len(inputs) {
+			t.Fatalf("workers %d: %d results, want %d", workers, len(results), len(inputs))
+		}
+		for i, r := range results {
+			if r.Name != inputs[i].Name {
+				t.Errorf("workers %d: result %d is for %s", workers, i, r.Name)
+			}
+			if i == 3 || i == 7 {
+				if r.Err == nil || r.Schema != nil {
+					t.Errorf("workers %d: %s converted, but should have failed", workers, r.Name)
+				}
+				continue
+			}
+			if r.Err != nil {
+				t.Errorf("workers %d: %s: %v", workers, r.Name, r.Err)
+				continue
+			}
+			if got := typeNames(r.Schema); strings.Join(got, ",") != fmt.Sprintf("T%d", i) {
+				t.Errorf("workers %d: %s has types %v", workers, r.Name, got)
+			}
+			if (i == 5) != (len(r.Warnings) == 1) {
+				t.Errorf("workers %d: %s has warnings %s", workers, r.Name, compact(r.Warnings))
+			}
+		}
+	}
+	if results, err := ConvertBatch(nil, Options{}); err != nil || len(results) != 0 {
+		t.Errorf("empty batch: %v, %v", results, err)
+	}
+}
+
+// TestStamp checks that Stamp records the input's hash and the importer
+// version, and only then.
+func TestStamp(t *testing.T) {
+	doc := swaggerDoc(`{}`, `{"T": {"type": "string"}}`)
+	sum := sha256.Sum256([]byte(doc))
+	tests := []struct {
+		stamp   bool
+		sha256  string
+		version string
+	}{
+		{false, "", ""},
+		{true, hex.EncodeToString(sum[:]), "1.2.3"},
+	}
+	for _, tt := range tests {
+		schema, _ := convertDoc(t, doc, Options{Stamp: tt.stamp, Version: "1.2.3"})
+		if got := schema.Annotations["x_source_sha256"]; got != tt.sha256 {
+			t.Errorf("stamp %v: x_source_sha256 is %q, want %q", tt.stamp, got, tt.sha256)
+		}
+		if got := schema.Annotations["x_generator_version"]; got != tt.version {
+			t.Errorf("stamp %v: x_generator_version is %q, want %q", tt.stamp, got, tt.version)
+		}
+	}
+}
+
+// TestAnnotations checks that Annotations are added to the schema, and that
+// their names must be identifiers starting with x_.
+func TestAnnotations(t *testing.T) {
+	doc := swaggerDoc(`{}`, `{"T": {"type": "string"}}`)
+	annotations := map[string]string{"x_go_package": "foo/bar", "x_java_package": "com.foo", "x_empty": "", "x_eq": "a=b"}
+	schema, _ := convertDoc(t, doc, Options{Annotations: annotations})
+	want := map[string]string{"x_go_package": "foo/bar", "x_java_package": "com.foo", "x_empty": "", "x_eq": "a=b"}
+	for k, v := range want {
+		if got, ok := schema.Annotations[rdl.ExtendedAnnotation(k)]; !ok || got != v {
+			t.Errorf("annotation %s is %q, want %q", k, got, v)
+		}
+	}
+	for _, name := range []string{"go_package", "x-go-package", "x_go package"} {
+		if _, err := Convert("test", []byte(doc), Options{Annotations: map[string]string{name: "v"}}); err == nil {
+			t.Errorf("no error for the annotation name %q", name)
+		}
+	}
+}
+
+// TestInfoExtensions checks that the x- keys of the info become annotations of
+// the schema, with objects and arrays as JSON, and that a key that cannot be
+// an annotation is skipped.
+func TestInfoExtensions(t *testing.T) {
+	tests := []struct {
+		info string
+		want map[rdl.ExtendedAnnotation]string
+	}{
+		{`"x-logo": {"url": "a.png", "alt": "say \"x\""}`, map[rdl.ExtendedAnnotation]string{"x_logo": `{"alt":"say \"x\"","url":"a.png"}`}},
+		{`"x-tags": ["a", "b"], "x-n": 3`, map[rdl.ExtendedAnnotation]string{"x_n": "3", "x_tags": `["a","b"]`}},
+		{`"x-audience": "public"`, map[rdl.ExtendedAnnotation]string{"x_audience": "public"}},
+		{`"x-bad key": 1`, nil},
+	}
+	for _, tt := range tests {
+		doc := `{"swagger": "2.0", "info": {"title": "t", "version": "1", ` + tt.info + `}, "paths": {}}`
+		schema, _ := convertDoc(t, doc, Options{})
+		if compact(schema.Annotations) != compact(tt.want) {
+			t.Errorf("%s: annotations are %s, want %s", tt.info, compact(schema.Annotations), compact(tt.want))
+		}
+	}
+}
+
+// TestCoverage checks that each feature counts against the innermost
+// construct containing it, dropped winning over approximated, and the
+// coverage of a document mixing the three.
+func TestCoverage(t *testing.T) {
+	constructs := []string{"definitions.P", "definitions.P.a", "definitions.P.ab", "paths./x.get"}
+	tests := []struct {
+		features []Feature
+		want     Coverage
+	}{
+		{nil, Coverage{Constructs: 4, Faithful: 4}},
+		{[]Feature{{Location: "definitions.P.a", Status: "dropped"}}, Coverage{Constructs: 4, Faithful: 3, Dropped: 1}},
+		{[]Feature{{Location: "definitions.P.ab.items", Status: "approximated"}}, Coverage{Constructs: 4, Faithful: 3, Approximated: 1}},
+		{[]Feature{{Location: "definitions.P", Status: "dropped"}, {Location: "definitions.P", Status: "approximated"}}, Coverage{Constructs: 4, Faithful: 3, Dropped: 1}},
+		{[]Feature{{Location: "info", Status: "dropped"}}, Coverage{Constructs: 4, Faithful: 4}},
+	}
+	for _, tt := range tests {
+		if got := coverage(constructs, tt.features); got != tt.want {
+			t.Errorf("coverage of %v is %+v, want %+v", tt.features, got, tt.want)
+		}
+	}
+	definitions := `{"P": {"type": "object", "properties": {"a": {"type": "string"}, "c": {"type": "array"}}}}`
+	paths := `{"/x": {"get": {"produces": ["text/csv"], "parameters": [{"name": "q", "in": "query", "type": "string"}], "responses": {"200": {"description": "ok", "schema": {"$ref": "#/definitions/P"}}}}}}`
+	_, rep := convertDoc(t, swaggerDoc(paths, definitions), Options{})
+	want := Coverage{Constructs: 6, Faithful: 4, Approximated: 1, Dropped: 1}
+	if rep.Coverage != want {
+		t.Errorf("coverage is %+v, want %+v", rep.Coverage, want)
+	}
+	if got, want := want.String(), "coverage: 6 constructs, 4 (66.7%) imported faithfully, 1 (16.7%) approximated, 1 (16.7%) dropped"; got != want {
+		t.Errorf("coverage reads %q, want %q", got, want)
+	}
+}
+
+// TestConvertWithReport checks the warnings, features, and coverage that
+// ConvertWithReport reports for a document, that it reports nothing for one
+// it cannot read, and the warnings given before the failure for one it cannot
+// convert.
+func TestConvertWithReport(t *testing.T) {
+	tests := []struct {
+		definitions string
+		paths       string
+		report      string
+	}{
+		{
+			`{"P": {"type": "object", "properties": {"a": {"type": "string"}}}}`,
+			`{}`,
+			`{"warnings":null,"features":null,"coverage":{"constructs":2,"faithful":2,"approximated":0,"dropped":0}}`,
+		},
+		{
+			`{"P": {"type": "object", "properties": {"c": {"type": "array"}}}}`,
+			`{"/x": {"get": {"produces": ["text/csv"], "responses": {"200": {"description": "ok", "schema": {"$ref": "#/definitions/P"}}}}}}`,
+			`{"warnings":[{"location":"definitions.P.c","message":"array c has no items, imported as an array of Any"},{"location":"paths./x.get","message":"expected to produce something other than application/json: text/csv"}],` +
+				`"features":[{"construct":"items","location":"definitions.P.c","reason":"array c has no items, imported as an array of Any","status":"approximated"},{"construct":"produces","location":"paths./x.get","reason":"expected to produce something other than application/json: text/csv","status":"dropped"}],` +
+				`"coverage":{"constructs":4,"faithful":2,"approximated":1,"dropped":1}}`,
+		},
+	}
+	for _, tt := range tests {
+		_, rep, err := ConvertWithReport("test", []byte(swaggerDoc(tt.paths, tt.definitions)), Options{})
+		if err != nil {
+			t.Errorf("%s: cannot convert: %v", tt.definitions, err)
+			continue
+		}
+		if got := compact(rep); got != tt.report {
+			t.Errorf("%s: report is %s, want %s", tt.definitions, got, tt.report)
+		}
+	}
+	schema, rep, err := ConvertWithReport("test", []byte(`{"swagger": "2.0"`), Options{})
+	if err == nil || schema != nil || rep != nil {
+		t.Errorf("bad document gave %v, %v, %v, want an error alone", schema, rep, err)
+	}
+	//a conversion that fails part way still reports the warnings before it
+	doc := swaggerDoc(`{}`, `{"A": {"type": "array"}, "B": {"type": "integer", "x-constraint": {"range": [2, 1]}}}`)
+	schema, rep, err = ConvertWithReport("test", []byte(doc), Options{})
+	if err == nil || schema != nil || rep == nil || len(rep.Warnings) != 1 || !hasWarning(rep, "array A has no items") {
+		t.Errorf("failed conversion gave %v, %s, %v, want the warning about A and an error", schema, compact(rep), err)
+	}
+}
@@ -0,0 +1,264 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+//
+// A semantic diff of two RDL schemas, for reviewing a regenerated schema.
+//
+
+// DiffSchemas writes the differences between two schemas, one per line: a
+// type or resource added (+), removed (-), or changed (~), with the details
+// of what changed in it indented beneath. Types are matched by name and
+// resources by method and path, so a renamed type shows as one removed and
+// another added. It returns the number of differences found.
+func DiffSchemas(w io.Writer, old, cur *rdl.Schema) int {
+	n := 0
+	line := func(format string, args ...interface{}) {
+		fmt.Fprintf(w, format+"\n", args...)
+	}
+	if old.Name != cur.Name {
+		line("~ name: %s -> %s", old.Name, cur.Name)
+		n++
+	}
+	if versionString(old.Version) != versionString(cur.Version) {
+		line("~ version: %s -> %s", versionString(old.Version), versionString(cur.Version))
+		n++
+	}
+	if old.Base != cur.Base {
+		line("~ base: %q -> %q", old.Base, cur.Base)
+		n++
+	}
+	oldTypes, curTypes := typesByName(old), typesByName(cur)
+	for _, name := range unionKeys(oldTypes, curTypes) {
+		ot, ct := oldTypes[name], curTypes[name]
+		switch {
+		case ot == nil:
+			line("+ type %s %s", name, typeKind(ct))
+		case ct == nil:
+			line("- type %s %s", name, typeKind(ot))
+		case !sameJSON(ot, ct):
+			line("~ type %s", name)
+			for _, d := range typeChanges(ot, ct) {
+				line("    %s", d)
+			}
+		default:
+			continue
+		}
+		n++
+	}
+	oldRez, curRez := resourcesByKey(old), resourcesByKey(cur)
+	for _, key := range unionKeys(oldRez, curRez) {
+		or, cr := oldRez[key], curRez[key]
+		switch {
+		case or == nil:
+			line("+ resource %s -> %s", key, cr.Type)
+		case cr == nil:
+			line("- resource %s -> %s", key, or.Type)
+		case !sameJSON(or, cr):
+			line("~ resource %s", key)
+			for _, d := range resourceChanges(or, cr) {
+				line("    %s", d)
+			}
+		default:
+			continue
+		}
+		n++
+	}
+	return n
+}
+
+func versionString(v *int32) string {
+	if v == nil {
+		return "none"
+	}
+	return fmt.Sprint(*v)
+}
+
+func typesByName(schema *rdl.Schema) map[string]*rdl.Type {
+	types := make(map[string]*rdl.Type)
+	for _, t := range schema.Types {
+		name, _, _ := rdl.TypeInfo(t)
+		types[string(name)] = t
+	}
+	return types
+}
+
+func resourcesByKey(schema *rdl.Schema) map[string]*rdl.Resource {
+	resources := make(map[string]*rdl.Resource)
+	for _, r := range schema.Resources {
+		resources[r.Method+" "+r.Path] = r
+	}
+	return resources
+}
+
+// unionKeys returns the keys of both maps, sorted.
+func unionKeys[V any](a, b map[string]V) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// typeKind describes a type by what it is derived from, e.g. "Struct".
+func typeKind(t *rdl.Type) string {
+	_, super, _ := rdl.TypeInfo(t)
+	return string(super)
+}
+
+func sameJSON(a, b interface{}) bool {
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	return string(ja) == string(jb)
+}
+
+// typeChanges describes how a type changed: field by field for structs, and
+// by the parts of its definition that differ otherwise.
+func typeChanges(ot, ct *rdl.Type) []string {
+	if ot.Variant != ct.Variant || ot.Variant != rdl.TypeVariantStructTypeDef {
+		return memberChanges(typeMembers(ot), typeMembers(ct))
+	}
+	od, cd := ot.StructTypeDef, ct.StructTypeDef
+	var changes []string
+	if od.Type != cd.Type {
+		changes = append(changes, fmt.Sprintf("~ supertype: %s -> %s", od.Type, cd.Type))
+	}
+	oldFields := make(map[string]*rdl.StructFieldDef)
+	for _, f := range od.Fields {
+		oldFields[string(f.Name)] = f
+	}
+	curFields := make(map[string]*rdl.StructFieldDef)
+	for _, f := range cd.Fields {
+		curFields[string(f.Name)] = f
+	}
+	for _, name := range unionKeys(oldFields, curFields) {
+		of, cf := oldFields[name], curFields[name]
+		switch {
+		case of == nil:
+			changes = append(changes, fmt.Sprintf("+ field %s %s", fieldType(cf), name))
+		case cf == nil:
+			changes = append(changes, fmt.Sprintf("- field %s %s", fieldType(of), name))
+		case fieldType(of) != fieldType(cf):
+			changes = append(changes, fmt.Sprintf("~ field %s: %s -> %s", name, fieldType(of), fieldType(cf)))
+		case of.Optional != cf.Optional:
+			changes = append(changes, fmt.Sprintf("~ field %s: optional %v -> %v", name, of.Optional, cf.Optional))
+		case !sameJSON(of, cf):
+			changes = append(changes, fmt.Sprintf("~ field %s", name))
+		}
+	}
+	rest := func(td *rdl.StructTypeDef) map[string]interface{} {
+		m := jsonMembers(td)
+		delete(m, "fields")
+		delete(m, "type")
+		return m
+	}
+	return append(changes, memberChanges(rest(od), rest(cd))...)
+}
+
+// fieldType returns a field's type as RDL writes it, e.g. Array<String>.
+func fieldType(f *rdl.StructFieldDef) string {
+	switch {
+	case f.Keys != "":
+		return fmt.Sprintf("%s<%s,%s>", f.Type, f.Keys, f.Items)
+	case f.Items != "":
+		return fmt.Sprintf("%s<%s>", f.Type, f.Items)
+	}
+	return string(f.Type)
+}
+
+// resourceChanges describes how a resource changed, input by input for its
+// inputs, and by the other parts of its definition that differ.
+func resourceChanges(or, cr *rdl.Resource) []string {
+	var changes []string
+	oldInputs := make(map[string]*rdl.ResourceInput)
+	for _, in := range or.Inputs {
+		oldInputs[string(in.Name)] = in
+	}
+	curInputs := make(map[string]*rdl.ResourceInput)
+	for _, in := range cr.Inputs {
+		curInputs[string(in.Name)] = in
+	}
+	for _, name := range unionKeys(oldInputs, curInputs) {
+		oi, ci := oldInputs[name], curInputs[name]
+		switch {
+		case oi == nil:
+			changes = append(changes, fmt.Sprintf("+ input %s %s", ci.Type, name))
+		case ci == nil:
+			changes = append(changes, fmt.Sprintf("- input %s %s", oi.Type, name))
+		case oi.Type != ci.Type:
+			changes = append(changes, fmt.Sprintf("~ input %s: %s -> %s", name, oi.Type, ci.Type))
+		case !sameJSON(oi, ci):
+			changes = append(changes, fmt.Sprintf("~ input %s", name))
+		}
+	}
+	om, cm := jsonMembers(or), jsonMembers(cr)
+	delete(om, "inputs")
+	delete(cm, "inputs")
+	return append(changes, memberChanges(om, cm)...)
+}
+
+func typeMembers(t *rdl.Type) map[string]interface{} {
+	m := jsonMembers(t)
+	for _, v := range m {
+		//a type is a union of its variants, only one of which is set
+		if vm, ok := v.(map[string]interface{}); ok {
+			return vm
+		}
+	}
+	return m
+}
+
+// jsonMembers returns the members of the value's JSON encoding.
+func jsonMembers(v interface{}) map[string]interface{} {
+	j, _ := json.Marshal(v)
+	var m map[string]interface{}
+	json.Unmarshal(j, &m)
+	if m == nil {
+		m = make(map[string]interface{})
+	}
+	return m
+}
+
+// memberChanges describes the members of two JSON objects that differ, giving
+// the old and new values of those short enough to show.
+func memberChanges(old, cur map[string]interface{}) []string {
+	var changes []string
+	for _, k := range unionKeys(old, cur) {
+		ov, ook := old[k]
+		cv, cok := cur[k]
+		switch {
+		case !ook:
+			changes = append(changes, fmt.Sprintf("+ %s: %s", k, shortJSON(cv)))
+		case !cok:
+			changes = append(changes, fmt.Sprintf("- %s: %s", k, shortJSON(ov)))
+		case !reflect.DeepEqual(ov, cv):
+			changes = append(changes, fmt.Sprintf("~ %s: %s -> %s", k, shortJSON(ov), shortJSON(cv)))
+		}
+	}
+	return changes
+}
+
+// shortJSON returns the value as JSON, elided if it is long.
+func shortJSON(v interface{}) string {
+	j, _ := json.Marshal(v)
+	s := string(j)
+	if len(s) > 60 {
+		s = s[:57] + "..."
+	}
+	return strings.Replace(s, "\n", " ", -1)
+}
@@ -0,0 +1,49 @@
+package importer
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestDiffSchemas checks the diff between the schemas converted from two
+// versions of a document, including a renamed type, which shows as one
+// removed and another added.
+func TestDiffSchemas(t *testing.T) {
+	paths := `{"/pets": {"get": {"responses": {"200": {"description": "ok", "schema": {"$ref": "#/definitions/Pet"}}}}}}`
+	pet := `{"Pet": {"type": "object", "required": ["name"], "properties": {"name": {"type": "string"}, "age": {"type": "integer"}}}`
+	tests := []struct {
+		oldPaths, oldDefinitions string
+		curPaths, curDefinitions string
+		want                     string
+		n                        int
+	}{
+		{paths, pet + `}`, paths, pet + `}`, "", 0},
+		{
+			paths, pet + `, "Tag": {"type": "string"}}`,
+			paths, pet + `, "Label": {"type": "string"}}`,
+			"+ type Label String\n- type Tag String\n", 2,
+		},
+		{
+			paths, pet + `}`,
+			paths, `{"Pet": {"type": "object", "properties": {"name": {"type": "string"}, "age": {"type": "string"}, "tag": {"type": "string"}}}}`,
+			"~ type Pet\n    ~ field age: Int32 -> String\n    ~ field name: optional false -> true\n    + field String tag\n", 1,
+		},
+		{
+			paths, pet + `}`,
+			`{"/pets": {"get": {"parameters": [{"name": "limit", "in": "query", "type": "integer"}], "responses": {"200": {"description": "ok", "schema": {"$ref": "#/definitions/Pet"}}}}}, "/tags": {"get": {"responses": {"200": {"description": "ok", "schema": {"type": "string"}}}}}}`, pet + `}`,
+			"~ resource GET /pets\n    + input Int32 limit\n+ resource GET /tags -> String\n", 2,
+		},
+	}
+	for _, tt := range tests {
+		old, _ := convertDoc(t, swaggerDoc(tt.oldPaths, tt.oldDefinitions), Options{})
+		cur, _ := convertDoc(t, swaggerDoc(tt.curPaths, tt.curDefinitions), Options{})
+		var buf bytes.Buffer
+		n := DiffSchemas(&buf, old, cur)
+		if got := buf.String(); got != tt.want {
+			t.Errorf("%s -> %s: diff is\n%s\nwant\n%s", tt.oldDefinitions, tt.curDefinitions, got, tt.want)
+		}
+		if n != tt.n {
+			t.Errorf("%s -> %s: %d differences, want %d", tt.oldDefinitions, tt.curDefinitions, n, tt.n)
+		}
+	}
+}
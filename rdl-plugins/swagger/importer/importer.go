@@ -0,0 +1,3012 @@
+// Package importer converts Swagger 2.0 documents, and the JSON Schema and
+// Postman collections that can be read as one, to RDL schemas. It is the
+// library behind the rdl-import-swagger command.
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/ardielle/ardielle-tools/rdl-plugins/swagger"
+)
+
+// importer holds the state of a single swagger to RDL conversion.
+type importer struct {
+	opts Options
+	doc  *swagger.Doc
+	sb   *rdl.SchemaBuilder
+
+	//the enum types synthesized for inline enums, keyed by their values
+	enums map[string]string
+
+	//the type names that inline schemas cannot take: the definitions, and
+	//the types already named after the title of an inline schema
+	typeNames map[string]bool
+
+	//the resource names in use, and the operationIds in the document, which
+	//derived names must avoid. The names given to operations with an
+	//operationId are kept in opNames.
+	names    map[string]bool
+	reserved map[string]bool
+	opNames  map[*swagger.Operation]string
+
+	//with FlattenPaths, the type name prefixes given to the operations
+	//without an operationId, and those prefixes, which must be distinct
+	flatNames   map[*swagger.Operation]string
+	flatClaimed map[string]bool
+
+	//the words rendered as acronyms in made up names, keyed by their lower
+	//case, e.g. "id" as "ID"
+	acronyms map[string]string
+
+	//the request variants of the definitions split by SplitReadOnly,
+	//keyed by the names of the definitions
+	variants map[string]string
+
+	//the nesting depth of the schema currently being imported
+	depth int
+
+	//the examples to check once the schema is built, with ValidateExamples
+	examples []example
+
+	//the warnings given so far
+	warnings warnings
+
+	//the constructs dropped or approximated so far, and the locations of all
+	//those met, for the coverage of the report
+	features   []Feature
+	constructs []string
+
+	//the location in the swagger document currently being imported
+	context []string
+}
+
+// example is a swagger example along with the type it illustrates.
+type example struct {
+	location string
+	typename string
+	value    interface{}
+}
+
+func (imp *importer) push(elem string) {
+	imp.context = append(imp.context, elem)
+}
+
+func (imp *importer) pop() {
+	imp.context = imp.context[:len(imp.context)-1]
+}
+
+// location returns the current location in the document, e.g. "definitions.User.address.items"
+func (imp *importer) location() string {
+	return strings.Join(imp.context, ".")
+}
+
+func (imp *importer) warn(format string, args ...interface{}) {
+	imp.warnings.add(imp.location(), format, args...)
+}
+
+// drop warns about a swagger construct that is dropped from the RDL, and
+// records it for the features report.
+func (imp *importer) drop(construct string, format string, args ...interface{}) {
+	imp.feature("dropped", construct, fmt.Sprintf(format, args...))
+}
+
+// approximate warns about a swagger construct that is only approximated in
+// the RDL, and records it for the features report.
+func (imp *importer) approximate(construct string, format string, args ...interface{}) {
+	imp.feature("approximated", construct, fmt.Sprintf(format, args...))
+}
+
+func (imp *importer) feature(status string, construct string, reason string) {
+	imp.features = append(imp.features, Feature{Construct: construct, Location: imp.location(), Reason: reason, Status: status})
+	imp.warn("%s", reason)
+}
+
+// encounter records a construct at the current location, such as a property
+// or an operation, for the coverage report.
+func (imp *importer) encounter() {
+	imp.constructs = append(imp.constructs, imp.location())
+}
+
+// sameExampleAsRef returns true if the schema refers to a definition with the
+// same example as its own.
+func (imp *importer) sameExampleAsRef(def swagger.Type) bool {
+	ref, ok := refTypeName(getString(def, "$ref"))
+	if !ok {
+		return false
+	}
+	target, ok := imp.doc.Definitions[ref]
+	return ok && reflect.DeepEqual(imp.example(target), imp.example(def))
+}
+
+// example returns the example given in a schema, unless examples are being
+// suppressed. The example of a string holding JSON may be given as the value
+// itself, in which case it is returned serialized.
+func (imp *importer) example(def swagger.Type) interface{} {
+	if imp.opts.NoExamples {
+		return nil
+	}
+	if _, ok := def["example"].(string); !ok && def["example"] != nil && isJSON(def) {
+		return annotationValue(def["example"])
+	}
+	return def["example"]
+}
+
+// maxDepth returns the limit on how deeply schemas may nest.
+func (imp *importer) maxDepth() int {
+	if imp.opts.MaxDepth > 0 {
+		return imp.opts.MaxDepth
+	}
+	return DefaultMaxDepth
+}
+
+// noteExample records an example for checking against its type once the
+// schema is built, if examples are being validated.
+func (imp *importer) noteExample(tname string, value interface{}) {
+	if imp.opts.ValidateExamples {
+		imp.examples = append(imp.examples, example{location: imp.location(), typename: tname, value: value})
+	}
+}
+
+// validateExamples reports, as warnings, the recorded examples that are not
+// valid instances of their types.
+func (imp *importer) validateExamples(schema *rdl.Schema) {
+	for _, ex := range imp.examples {
+		switch ex.typename {
+		case "Array", "Map", "Struct":
+			//the validator needs the items and keys of a named type
+			continue
+		}
+		if err := validateExample(schema, ex.typename, ex.value); err != nil {
+			imp.warnings.add(ex.location, "example does not match %s: %v", ex.typename, err)
+		}
+	}
+}
+
+// validateExample checks the value against the named type, treating a panic
+// in the validator as a failure to validate.
+func validateExample(schema *rdl.Schema, tname string, value interface{}) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("cannot validate: %v", r)
+		}
+	}()
+	v := rdl.Validate(schema, tname, value)
+	if !v.Valid {
+		return fmt.Errorf("%s", v.Error)
+	}
+	return nil
+}
+
+func (imp *importer) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s: %s", imp.location(), fmt.Sprintf(format, args...))
+}
+
+// titleName returns the name given by a title of the form "The X API", or an
+// empty string for any other title, including "The API".
+func titleName(title string) string {
+	if strings.HasPrefix(title, "The ") && strings.HasSuffix(title, " API") && len(title) > len("The  API") {
+		return strings.TrimSpace(title[4 : len(title)-4])
+	}
+	return ""
+}
+
+func swaggerToSchema(name string, doc *swagger.Doc, opts Options) (*rdl.Schema, *Report, error) {
+	if !opts.KeepName {
+		if s := titleName(doc.Info.Title); s != "" {
+			name = s
+		}
+	}
+	sb := rdl.NewSchemaBuilder(name).Comment(doc.Info.Description)
+	imp := &importer{opts: opts, doc: doc, sb: sb, enums: make(map[string]string), names: make(map[string]bool), reserved: make(map[string]bool), opNames: make(map[*swagger.Operation]string), flatNames: make(map[*swagger.Operation]string), flatClaimed: make(map[string]bool)}
+	if len(opts.Acronyms) > 0 {
+		imp.acronyms = make(map[string]string)
+		for _, a := range opts.Acronyms {
+			imp.acronyms[strings.ToLower(a)] = a
+		}
+	}
+	if doc.Info.Version != "" {
+		n, err := strconv.Atoi(doc.Info.Version)
+		if err == nil {
+			sb.Version(int32(n))
+		}
+	}
+	if doc.BasePath != "" {
+		sb.Base(doc.BasePath)
+	}
+	imp.normalizeSubschemas(doc)
+	if opts.SplitReadOnly {
+		imp.push("definitions")
+		imp.splitReadOnly()
+		imp.pop()
+	}
+	imp.typeNames = make(map[string]bool)
+	for k := range doc.Definitions {
+		imp.typeNames[camelize(k, imp.acronyms)] = true
+	}
+	imp.push("definitions")
+	for _, k := range sortedKeys(doc.Definitions) {
+		imp.push(k)
+		imp.encounter()
+		err := imp.importSwaggerType(k, doc.Definitions[k], false)
+		if err != nil {
+			return nil, imp.report(), err
+		}
+		imp.pop()
+	}
+	imp.pop()
+	if !opts.NoResources {
+		if err := imp.importPaths(doc); err != nil {
+			return nil, imp.report(), err
+		}
+	}
+	schema, err := sb.BuildParanoid()
+	if err != nil {
+		return nil, imp.report(), err
+	}
+	imp.validateExamples(schema)
+	if doc.ExternalDocs != nil {
+		schema.Annotations = addExternalDocs(schema.Annotations, doc.ExternalDocs)
+	}
+	if (len(opts.OnlyTags) > 0 || len(opts.ExcludeTags) > 0) && !opts.KeepUnused && !opts.NoResources {
+		pruneTypes(schema)
+	}
+	if opts.Unwrap {
+		unwrapTypes(schema)
+	}
+	if opts.InlineSingleUse {
+		inlineSingleUse(schema)
+	}
+	if opts.TypePrefix != "" {
+		prefixTypes(schema, opts.TypePrefix)
+	}
+	if opts.ResourceError {
+		addResourceError(schema)
+	}
+	if opts.DependencyOrder {
+		sortTypesByDependency(schema)
+	}
+	return schema, imp.report(), nil
+}
+
+// report returns what the conversion has found out so far.
+func (imp *importer) report() *Report {
+	return &Report{Warnings: imp.warnings, Features: imp.features, Coverage: coverage(imp.constructs, imp.features)}
+}
+
+// importPaths imports the operations of every path, in order of path.
+func (imp *importer) importPaths(doc *swagger.Doc) error {
+	imp.push("paths")
+	paths := make([]string, 0, len(doc.Paths))
+	for k, item := range doc.Paths {
+		paths = append(paths, k)
+		for _, op := range []*swagger.Operation{item.Get, item.Put, item.Post, item.Delete, item.Options, item.Head, item.Patch} {
+			if op != nil && op.OperationID != "" {
+				imp.reserved[op.OperationID] = true
+			}
+		}
+	}
+	sort.Strings(paths)
+	for _, k := range paths {
+		imp.push(k)
+		err := imp.importSwaggerResources(k, doc.Paths[k])
+		if err != nil {
+			return err
+		}
+		imp.pop()
+	}
+	imp.pop()
+	return nil
+}
+
+// addExternalDocs records an externalDocs object as an x_externalDocs annotation
+// holding its url, with any description in x_externalDocs_description.
+func addExternalDocs(anno map[rdl.ExtendedAnnotation]string, docs *swagger.ExternalDocs) map[rdl.ExtendedAnnotation]string {
+	anno = addAnnotation(anno, "x_externalDocs", docs.Url)
+	if docs.Description != "" {
+		anno = addAnnotation(anno, "x_externalDocs_description", docs.Description)
+	}
+	return anno
+}
+
+// securitySchemes returns the security schemes the operation may use, in
+// order of first mention, each with its type and, for an apiKey, where the
+// key goes (in) and what it is called (name). The operation's requirements
+// override the document's, and an empty list means none are needed.
+func (imp *importer) securitySchemes(op *swagger.Operation) []interface{} {
+	requirements := op.Security
+	if requirements == nil {
+		requirements = imp.doc.Security
+	}
+	var schemes []interface{}
+	seen := make(map[string]bool)
+	for _, req := range requirements {
+		names := make([]string, 0, len(req))
+		for name := range req {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			def := imp.doc.SecurityDefinitions[name]
+			if def == nil {
+				imp.warn("security requirement refers to undefined scheme %q", name)
+				continue
+			}
+			scheme := map[string]interface{}{"scheme": name, "type": def.Type}
+			if def.Type == "apiKey" {
+				if def.In != "header" && def.In != "query" {
+					imp.warn("apiKey security scheme %q has bad location: %q", name, def.In)
+				}
+				scheme["in"] = def.In
+				scheme["name"] = def.Name
+			}
+			schemes = append(schemes, scheme)
+		}
+	}
+	return schemes
+}
+
+func (imp *importer) importSwaggerResources(path string, handler *swagger.PathItem) error {
+	operations := []struct {
+		method string
+		op     *swagger.Operation
+	}{
+		{"get", handler.Get},
+		{"put", handler.Put},
+		{"post", handler.Post},
+		{"delete", handler.Delete},
+		{"options", handler.Options},
+		{"head", handler.Head},
+		{"patch", handler.Patch},
+	}
+	for _, o := range operations {
+		if o.op == nil || !imp.opts.selected(o.op) {
+			continue
+		}
+		err := imp.importSwaggerResource(path, o.method, o.op, mergeParameters(handler.Parameters, o.op.Parameters))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeParameters returns the parameters of an operation: those declared for
+// the whole path, except where the operation overrides one with the same name
+// and location, followed by the operation's own.
+func mergeParameters(shared []*swagger.Parameter, own []*swagger.Parameter) []*swagger.Parameter {
+	var params []*swagger.Parameter
+	for _, sp := range shared {
+		overridden := false
+		for _, p := range own {
+			if p.Name == sp.Name && p.In == sp.In {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			params = append(params, sp)
+		}
+	}
+	return append(params, own...)
+}
+
+func (imp *importer) importTypeName(tdef swagger.Type, simpleType string, format string) string {
+	if tdef["$ref"] != nil {
+		if name, ok := refTypeName(tdef["$ref"].(string)); ok {
+			return camelize(name, imp.acronyms)
+		}
+	}
+	if tdef["type"] != nil {
+		if types, _ := schemaTypes(tdef); len(types) == 1 {
+			if types[0] == "string" {
+				return stringType(tdef)
+			}
+			if types[0] == "integer" {
+				return imp.intType(tdef)
+			}
+			return canonicalTypeName(types[0])
+		}
+		return "Any"
+	}
+	switch simpleType {
+	case "integer":
+		return imp.intType(swagger.Type{"format": format})
+	case "string":
+		return stringType(swagger.Type{"format": format})
+	}
+	return canonicalTypeName(camelize(simpleType, imp.acronyms))
+}
+
+// producesBinary returns true if the media types are all binary, such as
+// image/png or application/octet-stream, rather than JSON, XML, or text, so
+// that a successful response is the bytes themselves.
+func producesBinary(produces []string) bool {
+	if len(produces) == 0 {
+		return false
+	}
+	for _, prod := range produces {
+		mt := strings.ToLower(strings.TrimSpace(strings.SplitN(prod, ";", 2)[0]))
+		switch {
+		case mt == "application/json", strings.HasSuffix(mt, "+json"):
+			return false
+		case mt == "application/xml", strings.HasSuffix(mt, "+xml"):
+			return false
+		case strings.HasPrefix(mt, "text/"), mt == "*/*":
+			return false
+		}
+	}
+	return true
+}
+
+// importResponseType returns the type name of a response schema. An inline
+// enum gets a synthesized type named after the operation.
+func (imp *importer) importResponseType(path string, method string, op *swagger.Operation, schema swagger.Type) (string, error) {
+	if schema["$ref"] == nil && schema["enum"] != nil {
+		return imp.importInlineEnum(imp.operationTypeName(path, method, op)+"Response", schema)
+	}
+	return imp.importTypeName(schema, "?", ""), nil
+}
+
+// importParamType returns the type name of a parameter. A body schema that
+// needs a typedef of its own, such as an inline object or allOf, is imported
+// like a definition, as a type named after the operation. So is an array body,
+// as an input cannot give the type of its items.
+func (imp *importer) importParamType(path string, method string, op *swagger.Operation, param *swagger.Parameter) (string, error) {
+	schema := param.Schema
+	if schema == nil || !requiresTypeDef(schema) && getString(schema, "type") != "array" {
+		return imp.importTypeName(schema, param.Type, param.Format), nil
+	}
+	imp.push("parameters")
+	imp.push(param.Name)
+	defer imp.pop()
+	defer imp.pop()
+	name := imp.inlineTypeName(schema, imp.operationTypeName(path, method, op)+"Request")
+	if schema["enum"] != nil {
+		return imp.importInlineEnum(name, schema)
+	}
+	return name, imp.importSwaggerType(name, schema, false)
+}
+
+// successRank orders the responses of an operation: the successes with a
+// schema first, then those without, then the rest.
+func successRank(a map[string]string) int {
+	switch {
+	case a["type"] == "":
+		return 1
+	case a["code"][0] == '2':
+		return 0
+	}
+	return 2
+}
+
+// isStatusRange returns true for a response key that stands for a range of
+// status codes, such as 2XX.
+func isStatusRange(scode string) bool {
+	return len(scode) == 3 && scode[0] >= '1' && scode[0] <= '5' && strings.EqualFold(scode[1:], "XX")
+}
+
+// operationTypeName returns a type name prefix for the operation, from its
+// resource name if it has an operationId, otherwise from the method and path,
+// e.g. GetPetsIdStatus. With FlattenPaths the path is flattened first, and
+// a numeric suffix keeps apart the operations whose paths flatten the same.
+func (imp *importer) operationTypeName(path string, method string, op *swagger.Operation) string {
+	if op.OperationID != "" {
+		return imp.capitalize(imp.operationName(op))
+	}
+	if imp.opts.FlattenPaths {
+		if name, ok := imp.flatNames[op]; ok {
+			return name
+		}
+		base := imp.pathTypeName(flatPath(path), method)
+		name := base
+		for i := 2; imp.flatClaimed[name]; i++ {
+			name = base + strconv.Itoa(i)
+		}
+		imp.flatNames[op] = name
+		imp.flatClaimed[name] = true
+		return name
+	}
+	return imp.pathTypeName(path, method)
+}
+
+// pathTypeName returns a type name prefix made from the method and path.
+func (imp *importer) pathTypeName(path string, method string) string {
+	s := imp.capitalize(strings.ToLower(method))
+	for _, seg := range strings.FieldsFunc(path, func(c rune) bool {
+		return !(c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9'))
+	}) {
+		s += imp.capitalize(seg)
+	}
+	return s
+}
+
+// resourceName derives a name for an operation without an operationId from
+// its method and path, e.g. getUsersById for GET /users/{id}. A numeric suffix
+// keeps it distinct from the names already in use, such as when two paths
+// differ only by a trailing slash.
+func (imp *importer) resourceName(path string, method string) string {
+	base := strings.ToLower(method)
+	for _, seg := range strings.Split(path, "/") {
+		prefix := ""
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			prefix = "By"
+		}
+		for _, word := range strings.FieldsFunc(seg, func(c rune) bool {
+			return !(c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9'))
+		}) {
+			base += prefix + imp.capitalize(word)
+			prefix = ""
+		}
+	}
+	return imp.uniqueName(base)
+}
+
+// flatPath returns the path without its templated segments, e.g. /users/orders
+// for /users/{id}/orders/{orderId}.
+func flatPath(path string) string {
+	var segments []string
+	for _, seg := range strings.Split(path, "/") {
+		if seg != "" && !strings.Contains(seg, "{") {
+			segments = append(segments, seg)
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+// parameterComment returns the resource comment followed by a list of the
+// inputs that have descriptions, one per line.
+func parameterComment(comment string, inputs []*rdl.ResourceInput) string {
+	var lines []string
+	for _, in := range inputs {
+		if in.Comment != "" {
+			lines = append(lines, "- "+string(in.Name)+": "+strings.Join(strings.Fields(in.Comment), " "))
+		}
+	}
+	if len(lines) == 0 {
+		return comment
+	}
+	if comment != "" {
+		comment += "\n\n"
+	}
+	return comment + strings.Join(lines, "\n")
+}
+
+// maxSummaryWords is the most words a summary can have and still be used as
+// a resource name.
+const maxSummaryWords = 5
+
+// summaryName returns a resource name made from an operation's summary, such
+// as getUserById for "Get user by id", if summaries are used as names and this
+// one is short enough to make a sensible one. Otherwise it returns "".
+func (imp *importer) summaryName(summary string) string {
+	if !imp.opts.SummaryAsName {
+		return ""
+	}
+	words := strings.FieldsFunc(summary, func(c rune) bool {
+		return !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9'))
+	})
+	if len(words) == 0 || len(words) > maxSummaryWords {
+		return ""
+	}
+	name := strings.ToLower(words[0])
+	for _, word := range words[1:] {
+		name += imp.capitalize(word)
+	}
+	if !isIdentifier(name) || name[0] < 'a' || name[0] > 'z' {
+		return ""
+	}
+	return imp.uniqueName(name)
+}
+
+// operationName returns the resource name for an operation with an
+// operationId. That is normally the operationId itself, but an operationId
+// that is used more than once gets a numeric suffix after its first use.
+func (imp *importer) operationName(op *swagger.Operation) string {
+	if name, ok := imp.opNames[op]; ok {
+		return name
+	}
+	name := op.OperationID
+	if imp.names[name] {
+		name = imp.uniqueName(name)
+		imp.approximate("operationId", "operationId %s is already in use, naming the resource %s", op.OperationID, name)
+	} else {
+		imp.names[name] = true
+	}
+	imp.opNames[op] = name
+	return name
+}
+
+// uniqueName returns the base name, or the base with the lowest numeric suffix
+// that makes it distinct from the names in use and the operationIds, and
+// marks the result as in use.
+func (imp *importer) uniqueName(base string) string {
+	name := base
+	for i := 2; imp.names[name] || imp.reserved[name]; i++ {
+		name = base + strconv.Itoa(i)
+	}
+	imp.names[name] = true
+	return name
+}
+
+func (imp *importer) importSwaggerResource(path string, method string, op *swagger.Operation, params []*swagger.Parameter) error {
+	imp.push(method)
+	defer imp.pop()
+	imp.encounter()
+	if op.OperationID != "" {
+		//claim the name before any types are named after it
+		imp.operationName(op)
+	}
+	produces := op.Produces
+	if produces == nil {
+		produces = imp.doc.Produces
+	}
+	binary := producesBinary(produces)
+	tname := "?"
+	expected := "OK"
+	alts := make([]map[string]string, 0)
+	var ranges []string
+	scodes := make([]string, 0, len(op.Responses))
+	for scode := range op.Responses {
+		scodes = append(scodes, scode)
+	}
+	sort.Strings(scodes)
+	for _, scode := range scodes {
+		resp := op.Responses[scode]
+		imp.push("responses")
+		imp.push(scode)
+		imp.encounter()
+		code := scode
+		if isStatusRange(scode) {
+			//a range is represented by its first code, unless that is given explicitly
+			code = scode[:1] + "00"
+			if _, ok := op.Responses[code]; ok {
+				imp.drop("responses", "ignoring response for %s, which overlaps the response for %s", scode, code)
+				imp.pop()
+				imp.pop()
+				continue
+			}
+			ranges = append(ranges, strings.ToUpper(scode))
+		}
+		rtype := ""
+		if binary && code[0] == '2' && code != "204" {
+			//the content is the image, or whatever, itself, not JSON
+			rtype = "Bytes"
+		} else if len(resp.Schema) > 0 || code[0] != '2' {
+			var err error
+			rtype, err = imp.importResponseType(path, method, op, resp.Schema)
+			if err != nil {
+				return err
+			}
+			if rtype == "?" && imp.opts.ResourceError && scode != "default" {
+				//an error response without a schema
+				rtype = "ResourceError"
+			}
+		}
+		imp.pop()
+		imp.pop()
+		if scode == "default" {
+			tname = rtype
+		} else {
+			alts = append(alts, map[string]string{"type": rtype, "code": code})
+		}
+	}
+	if len(op.Responses) == 0 {
+		//e.g. a fire-and-forget POST: treat it as succeeding with no content
+		imp.approximate("responses", "no responses given, imported as NO_CONTENT")
+		alts = append(alts, map[string]string{"type": "", "code": "204"})
+	}
+	//a success response with a schema gives the type, ahead of one without
+	sort.SliceStable(alts, func(i, j int) bool {
+		return successRank(alts[i]) < successRank(alts[j])
+	})
+	var exceptions map[string]*rdl.ExceptionDef
+	var alternatives []string
+	noContent := false
+	for _, a := range alts {
+		if a["type"] == "" {
+			//a success response without a schema has no content
+			if tname == "?" {
+				noContent = true
+				tname = "Any"
+				expected = "NO_CONTENT"
+				if a["code"] != "204" {
+					imp.approximate("responses", "%s response has no schema, imported as NO_CONTENT", a["code"])
+				}
+			} else if a["code"] != "200" || expected != "OK" {
+				alternatives = append(alternatives, a["code"])
+			}
+		} else if tname == "?" {
+			tname = canonicalTypeName(a["type"])
+		} else if a["type"] == tname {
+			alternatives = append(alternatives, a["code"])
+		} else {
+			if exceptions == nil {
+				exceptions = make(map[string]*rdl.ExceptionDef)
+			}
+			exceptions[a["code"]] = &rdl.ExceptionDef{Type: a["type"]}
+		}
+	}
+	rb := rdl.NewResourceBuilder(tname, strings.ToUpper(method), path).Comment(op.Summary)
+	rb.Expected(expected)
+	if len(alternatives) > 0 {
+		//fmt.Println("FIXME: rdl.ResourceBuilder needs a .Alternative(code) method")
+		//see below for just setting it after we build
+	}
+	if len(exceptions) > 0 {
+		for k, v := range exceptions {
+			rb.Exception(k, v.Type, v.Comment)
+		}
+	}
+	if op.OperationID != "" {
+		//only set this if it is not the default
+		name := imp.operationName(op)
+		rezName := strings.ToLower(method) + tname
+		if rezName != name || noContent {
+			rb.Name(name)
+		}
+	} else if name := imp.summaryName(op.Summary); name != "" {
+		rb.Name(name)
+	} else if imp.opts.FlattenPaths {
+		rb.Name(imp.resourceName(flatPath(path), method))
+	} else {
+		rb.Name(imp.resourceName(path, method))
+	}
+	consumes := op.Consumes
+	if consumes == nil {
+		consumes = imp.doc.Consumes
+	}
+	for _, prod := range produces {
+		if prod != "application/json" && !binary {
+			imp.drop("produces", "expected to produce something other than application/json: %s", prod)
+		}
+	}
+	inputAnnotations := make(map[rdl.Identifier]map[rdl.ExtendedAnnotation]string)
+	identifiers := make(map[string]bool)
+	for _, param := range params {
+		if param.In == "path" {
+			identifiers[strings.Replace(param.Name, "-", "_", -1)] = true
+		}
+	}
+	for _, param := range params {
+		pparam := false
+		qparam := ""
+		header := ""
+		imp.push("parameters")
+		imp.push(param.Name)
+		imp.encounter()
+		switch param.In {
+		case "path":
+			pparam = true
+		case "query":
+			qparam = param.Name
+		case "body":
+		case "header":
+			header = param.Name //this is an HTTP Header (a fairly general string), not an Identifier
+		default:
+			//not supported: formData
+			imp.approximate("parameters", "%s parameter %s imported as the request body", param.In, param.Name)
+		}
+		imp.pop()
+		imp.pop()
+		identifier := strings.Replace(param.Name, "-", "_", -1)
+		if param.In == "body" && op.CodegenRequestBodyName != "" {
+			bodyName := strings.Replace(op.CodegenRequestBodyName, "-", "_", -1)
+			if !isIdentifier(bodyName) || identifiers[bodyName] {
+				imp.warn("cannot name the body parameter %q, keeping %q", op.CodegenRequestBodyName, param.Name)
+			} else {
+				identifier = bodyName
+			}
+		}
+		if param.In != "path" {
+			//the path template binds by name, so a same-named query or header parameter is renamed
+			if identifiers[identifier] {
+				identifier += "_" + param.In
+			}
+			identifiers[identifier] = true
+		}
+		optional := false
+		var defval interface{}
+		if param.In == "body" && len(imp.variants) > 0 {
+			//the body is a request, so it takes the request variants of split definitions
+			p := *param
+			p.Schema = imp.requestSchema(param.Schema).(map[string]interface{})
+			param = &p
+		}
+		var ptype string
+		if param.Type == "file" || getString(param.Schema, "type") == "file" {
+			if param.In != "formData" {
+				imp.push("parameters")
+				imp.push(param.Name)
+				imp.warn("a file parameter must be in formData, not %s", param.In)
+				imp.pop()
+				imp.pop()
+			}
+			ptype = "Bytes"
+			inputAnnotations[rdl.Identifier(identifier)] = addAnnotation(inputAnnotations[rdl.Identifier(identifier)], "x_file", true)
+		} else {
+			var err error
+			ptype, err = imp.importParamType(path, method, op, param)
+			if err != nil {
+				return err
+			}
+		}
+		rb.Input(identifier, ptype, pparam, qparam, header, optional, defval, param.Description)
+		if param.Type == "array" && param.CollectionFormat != "csv" {
+			if param.CollectionFormat == "multi" && param.In != "query" && param.In != "formData" {
+				imp.warn("collectionFormat multi is only valid for query and formData parameters, not %s parameter %s", param.In, param.Name)
+			}
+			inputAnnotations[rdl.Identifier(identifier)] = addAnnotation(inputAnnotations[rdl.Identifier(identifier)], "x_collectionFormat", param.CollectionFormat)
+		}
+		if param.Deprecated {
+			if param.In == "path" {
+				imp.warn("path parameter %s is deprecated, but cannot be left out", param.Name)
+			}
+			inputAnnotations[rdl.Identifier(identifier)] = addAnnotation(inputAnnotations[rdl.Identifier(identifier)], "x_deprecated", true)
+		}
+	}
+	r := rb.Build()
+	for _, in := range r.Inputs {
+		if anno, ok := inputAnnotations[in.Name]; ok {
+			in.Annotations = anno
+		}
+		if noContent && !in.PathParam && in.QueryParam == "" && in.Header == "" {
+			//with nothing returned, the resource is of the type it is given, as in RDL
+			r.Type = in.Type
+		}
+	}
+	if imp.opts.VerboseComments {
+		r.Comment = parameterComment(r.Comment, r.Inputs)
+	}
+	if len(alternatives) > 0 {
+		r.Alternatives = alternatives
+	}
+	if len(ranges) > 0 {
+		r.Annotations = addAnnotation(r.Annotations, "x_response_ranges", strings.Join(ranges, ","))
+	}
+	if len(consumes) > 0 {
+		r.Consumes = consumes
+	}
+	if len(produces) > 0 {
+		r.Produces = produces
+	}
+	if binary {
+		r.Annotations = addAnnotation(r.Annotations, "x_produces", strings.Join(produces, ","))
+	}
+	if op.Tags != nil && len(op.Tags) > 0 {
+		if r.Annotations == nil {
+			r.Annotations = make(map[rdl.ExtendedAnnotation]string)
+		}
+		r.Annotations["x_tags"] = strings.Join(op.Tags, ",")
+	}
+	if op.ExternalDocs != nil {
+		r.Annotations = addExternalDocs(r.Annotations, op.ExternalDocs)
+	}
+	if auth := imp.securitySchemes(op); len(auth) > 0 {
+		r.Annotations = addAnnotation(r.Annotations, "x_auth", auth)
+	}
+	err := imp.setDefaultParamTypes(r)
+	if err != nil {
+		return err
+	}
+	imp.sb.AddResource(r)
+	return nil
+}
+
+func (imp *importer) setDefaultParamTypes(r *rdl.Resource) error {
+	//parse the path template
+	path := r.Path
+	i := strings.Index(path, "{")
+	for i >= 0 {
+		j := strings.Index(path[i:], "}")
+		if j < 0 {
+			return imp.errorf("bad path template syntax: %s", path)
+		}
+		j += i
+		name := path[i+1 : j]
+		k := strings.Index(name, ":")
+		if k >= 0 {
+			if k == 0 {
+				imp.warn("bad path template syntax: %s", path)
+			}
+			name = name[0:k]
+		}
+		ok := false
+		for _, in := range r.Inputs {
+			if in.PathParam && string(in.Name) == name {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return imp.errorf("Resource input '%s' in '%s %s' has no corresponding type declaration", name, r.Method, r.Path)
+		}
+		i = strings.Index(path[j+1:], "{")
+		if i >= 0 {
+			i += j + 1
+		}
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]swagger.Type) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedProperties returns the property names of a schema in order, so that
+// fields, and the types synthesized for them, come out the same every time.
+func sortedProperties(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func getString(m map[string]interface{}, k string) string {
+	if o, ok := m[k]; ok {
+		if s, ok := o.(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+func getInt(m map[string]interface{}, k string) int32 {
+	if o, ok := m[k]; ok {
+		switch n := o.(type) {
+		case int:
+			return int32(n)
+		case int32:
+			return n
+		case int64:
+			return int32(n)
+		case float32:
+			return int32(n)
+		case float64:
+			return int32(n)
+		}
+	}
+	return -1
+}
+
+func getFloat(m map[string]interface{}, k string) float64 {
+	if o, ok := m[k]; ok {
+		switch n := o.(type) {
+		case int:
+			return float64(n)
+		case int32:
+			return float64(n)
+		case int64:
+			return float64(n)
+		case float32:
+			return float64(n)
+		case float64:
+			return n
+		}
+	}
+	return -1
+}
+
+func (imp *importer) importSwaggerType(name string, def swagger.Type, fromFieldSpec bool) error {
+	if name == "ResourceError" {
+		return nil
+	}
+	imp.depth++
+	defer func() { imp.depth-- }()
+	if imp.depth > imp.maxDepth() {
+		return imp.errorf("schema for %s is nested more than %d deep", name, imp.maxDepth())
+	}
+	name = camelize(name, imp.acronyms)
+	base := "Struct"
+	def = imp.unwrapAllOfRef(def)
+	if def["allOf"] != nil {
+		merged, b, err := imp.mergeAllOf(name, def)
+		if err != nil {
+			return err
+		}
+		def = merged
+		if b != "" {
+			base = b
+		}
+	}
+	scalarUnion := false
+	if types, ok := scalarUnionTypes(def); ok {
+		//import it as a union, as if the type were an array of the scalar types
+		udef := make(swagger.Type, len(def))
+		for k, v := range def {
+			udef[k] = v
+		}
+		delete(udef, "oneOf")
+		delete(udef, "anyOf")
+		delete(udef, "format")
+		udef["type"] = types
+		def = udef
+		scalarUnion = true
+	}
+	requiredFields := make(map[string]bool)
+	if def["required"] != nil {
+		required := def["required"].([]interface{})
+		for _, r := range required {
+			requiredFields[r.(string)] = true
+		}
+	}
+	def, nullable := resolveNullable(def)
+	dtype := getString(def, "type")
+	super := ""
+	if ref, ok := refTypeName(getString(def, "$ref")); ok {
+		//a $ref takes precedence over any type given alongside it, except
+		//constraints, which narrow it: they make a type derived from it
+		dtype = "ref"
+		if hasRefConstraints(def) {
+			if base := imp.scalarDefinition(ref, 0); base != nil {
+				def = refConstraints(def)
+				def["type"], def["format"] = base["type"], base["format"]
+				dtype = getString(def, "type")
+				super = camelize(ref, imp.acronyms)
+			} else {
+				imp.drop("$ref", "constraints alongside the $ref to %s, which is not a string or number type, are ignored", ref)
+			}
+		}
+	} else if dtype == "" {
+		if def["properties"] != nil {
+			dtype = "object"
+		} else if def["items"] != nil {
+			dtype = "array"
+		} else if _, ok := def["type"].([]interface{}); ok {
+			dtype = "union"
+		}
+	}
+	var t *rdl.Type
+	switch dtype {
+	case "ref":
+		ref, _ := refTypeName(getString(def, "$ref"))
+		tb := rdl.NewAliasTypeBuilder(camelize(ref, imp.acronyms), name)
+		if !fromFieldSpec {
+			tb.Comment(getString(def, "description"))
+		}
+		t = tb.Build()
+	case "union":
+		t = imp.importSwaggerUnionType(name, def, fromFieldSpec)
+	case "object":
+		if def["properties"] == nil && isMapLike(def) {
+			mt, err := imp.importSwaggerMapType(name, def, fromFieldSpec)
+			if err != nil {
+				return imp.errorf("%v", err)
+			}
+			t = mt
+			break
+		}
+		if def["properties"] == nil && def["additionalProperties"] == nil {
+			//a free-form object, as opposed to one with an explicitly empty set of properties
+			switch imp.opts.EmptyObject {
+			case "any":
+				t = rdl.NewAliasTypeBuilder("Any", name).Comment(getString(def, "description")).Build()
+			case "map":
+				t, _ = imp.importSwaggerMapType(name, def, fromFieldSpec)
+			}
+			if t != nil {
+				break
+			}
+		}
+		if def["minProperties"] != nil || def["maxProperties"] != nil {
+			imp.drop("minProperties", "minProperties/maxProperties ignored on struct type %s", name)
+		}
+		if required, ok := def["required"].([]interface{}); ok {
+			for _, r := range required {
+				fname, _ := r.(string)
+				if !imp.definesProperty(def, fname, nil) && !imp.definesProperty(imp.definition(base), fname, nil) {
+					imp.warn("required property %q of %s is not defined", fname, name)
+				}
+			}
+		}
+		tb := rdl.NewStructTypeBuilder(base, name).Comment(getString(def, "description"))
+		if !fromFieldSpec {
+			tb.Comment(getString(def, "description"))
+		}
+		if def["properties"] != nil {
+			properties := def["properties"].(map[string]interface{})
+			for _, fname := range sortedProperties(properties) {
+				imp.push(fname)
+				imp.encounter()
+				imp.pop()
+				fdef, _ := resolveNullable(properties[fname].(map[string]interface{}))
+				fdef = imp.unwrapAllOfRef(fdef)
+				optional := true
+				if required, ok := requiredFields[fname]; required && ok {
+					optional = false
+				}
+				if ref, ok := refTypeName(getString(fdef, "$ref")); ok && hasRefConstraints(fdef) && imp.scalarDefinition(ref, 0) == nil {
+					imp.push(fname)
+					imp.drop("$ref", "constraints alongside the $ref to %s, which is not a string or number type, are ignored", ref)
+					imp.pop()
+					fdef = swagger.Type{"$ref": fdef["$ref"], "description": fdef["description"]}
+				}
+				ftype, _ := imp.normalizeTypeName(fdef)
+				if ftype == "" {
+					//a schema with neither type nor $ref, such as {}, allows any value
+					ftype = "Any"
+				}
+				if requiresTypeDef(fdef) {
+					ftype = imp.inlineTypeName(fdef, name+"_"+imp.capitalize(fname))
+					imp.push(fname)
+					var err error
+					if msEnum(fdef) != nil {
+						//an x-ms-enum is named, and shared by the fields that repeat it
+						ftype, err = imp.importInlineEnum(ftype, fdef)
+					} else {
+						err = imp.importSwaggerType(ftype, fdef, true)
+					}
+					if err != nil {
+						return err
+					}
+					imp.pop()
+				} else {
+					switch strings.ToLower(ftype) {
+					case "bool", "string", "int32", "int16", "int8", "int64", "float64", "float32", "bytes":
+					case "timestamp", "symbol", "uuid", "array", "map", "struct", "enum", "union":
+					default:
+						//user-defined type. Must resolve, no forward refs.
+						//fmt.Println("typedef not required for field:", fname, "in type", name, "->", strings.ToLower(ftype))
+					}
+				}
+				if def, ok := fdef["default"]; ok {
+					imp.push(fname)
+					imp.checkEnumDefault(fdef, def)
+					imp.pop()
+				}
+				tb.Field(fname, ftype, optional, fieldDefault(fdef), getString(fdef, "description"))
+			}
+		}
+		t = tb.Build()
+		if def["additionalProperties"] == false {
+			//a closed struct, for which an unknown field is an error
+			t.StructTypeDef.Annotations = addAnnotation(t.StructTypeDef.Annotations, "x_closed", true)
+		}
+		if imp.example(def) != nil && !fromFieldSpec {
+			t.StructTypeDef.Annotations = addAnnotation(t.StructTypeDef.Annotations, "x_example", imp.example(def))
+			imp.noteExample(name, imp.example(def))
+		}
+		if def["properties"] != nil {
+			properties := def["properties"].(map[string]interface{})
+			for _, fname := range sortedProperties(properties) {
+				fdef, fnullable := resolveNullable(properties[fname].(map[string]interface{}))
+				for _, f := range t.StructTypeDef.Fields {
+					if f.Name == rdl.Identifier(fname) {
+						//the field's own example wins over its type's, which is not repeated on the field
+						if imp.example(fdef) != nil && !imp.sameExampleAsRef(fdef) {
+							f.Annotations = addAnnotation(f.Annotations, "x_example", imp.example(fdef))
+							imp.push(fname)
+							imp.noteExample(string(f.Type), imp.example(fdef))
+							imp.pop()
+						}
+						if fnullable {
+							f.Annotations = addAnnotation(f.Annotations, "x_nullable", true)
+						}
+						if docs, ok := fdef["externalDocs"].(map[string]interface{}); ok && !requiresTypeDef(fdef) {
+							//a field type of its own carries them instead
+							f.Annotations = addAnnotation(f.Annotations, "x_externalDocs", docs["url"])
+							f.Annotations = addAnnotation(f.Annotations, "x_externalDocs_description", docs["description"])
+						}
+						if requiresTypeDef(fdef) || fdef["$ref"] != nil {
+							continue
+						}
+						if getString(fdef, "type") == "string" {
+							f.Annotations = addAnnotation(f.Annotations, "x_format", formatAnnotation(fdef))
+						}
+						if d, ok := fdef["default"].(string); ok && getString(fdef, "format") == "duration" && !isDuration(d) {
+							imp.push(fname)
+							imp.warn("default %q is not an ISO 8601 duration", d)
+							imp.pop()
+						}
+						if d, ok := fdef["default"].(string); ok && isTime(fdef) && !timePattern.MatchString(d) {
+							imp.push(fname)
+							imp.warn("default %q is not an RFC 3339 time", d)
+							imp.pop()
+						}
+						if isChar(fdef) {
+							f.Annotations = addAnnotation(f.Annotations, "x_format_char", true)
+						}
+						if isJSON(fdef) {
+							f.Annotations = addAnnotation(f.Annotations, "x_format_json", true)
+						}
+						if isTime(fdef) {
+							f.Annotations = addAnnotation(f.Annotations, "x_format_time", true)
+						}
+						if fdef["readOnly"] == true {
+							f.Annotations = addAnnotation(f.Annotations, "x_readOnly", true)
+						}
+						if fdef["writeOnly"] == true {
+							f.Annotations = addAnnotation(f.Annotations, "x_writeOnly", true)
+						}
+						if enc := bytesEncoding(fdef); enc != "" && getString(fdef, "type") == "string" {
+							f.Annotations = addAnnotation(f.Annotations, "x_encoding", enc)
+						}
+						if isDate(fdef) {
+							f.Annotations = addAnnotation(f.Annotations, "x_format_date", true)
+						}
+						if idef, ok := fdef["items"].(map[string]interface{}); ok && f.Type == "Array" {
+							if items, _ := imp.normalizeTypeName(idef); items != "" {
+								f.Items = rdl.TypeRef(items)
+							} else {
+								f.Items = "Any"
+							}
+						} else if isMapLike(fdef) && f.Type == "Struct" {
+							f.Type, f.Keys, f.Items = "Map", "String", "Any"
+							if vdef, ok := fdef["additionalProperties"].(map[string]interface{}); ok {
+								if items, _ := imp.normalizeTypeName(vdef); items != "" {
+									f.Items = rdl.TypeRef(items)
+								}
+							}
+						} else if f.Type == "Struct" && fdef["properties"] == nil && fdef["additionalProperties"] == nil {
+							//a free-form object, imported as EmptyObject says
+							switch imp.opts.EmptyObject {
+							case "any":
+								f.Type = "Any"
+							case "map":
+								f.Type, f.Keys, f.Items = "Map", "String", "Any"
+							}
+						} else if f.Type == "Array" && fdef["items"] == nil {
+							imp.push(fname)
+							imp.approximate("items", "array %s has no items, imported as an array of Any", fname)
+							imp.pop()
+							f.Items = "Any"
+						}
+					}
+				}
+			}
+		}
+		if t.StructTypeDef.Fields == nil {
+			t.StructTypeDef.Fields = make([]*rdl.StructFieldDef, 0)
+		}
+		if imp.opts.FieldCase == "camel" || imp.opts.FieldCase == "snake" {
+			imp.normalizeFieldNames(t.StructTypeDef)
+		}
+	case "array":
+		tb := rdl.NewArrayTypeBuilder("Array", name)
+		if !fromFieldSpec {
+			tb.Comment(getString(def, "description"))
+		}
+		if def["items"] != nil {
+			imp.push("items")
+			ftype, err := imp.importElementType(name+"_Item", def["items"].(map[string]interface{}))
+			if err != nil {
+				return err
+			}
+			imp.pop()
+			tb.Items(ftype)
+		} else {
+			imp.approximate("items", "array %s has no items, imported as an array of Any", name)
+			tb.Items("Any")
+		}
+		t = tb.Build()
+		length, err := constraintLength(name, def, "minItems", "maxItems")
+		if err != nil {
+			return imp.errorf("%v", err)
+		}
+		if length >= 0 {
+			t.ArrayTypeDef.MinSize = &length
+			t.ArrayTypeDef.MaxSize = &length
+		}
+		if def["minItems"] != nil {
+			t.ArrayTypeDef.Annotations = addAnnotation(t.ArrayTypeDef.Annotations, "x_minItems", def["minItems"])
+		}
+		if imp.example(def) != nil {
+			t.ArrayTypeDef.Annotations = addAnnotation(t.ArrayTypeDef.Annotations, "x_example", imp.example(def))
+			imp.noteExample(name, imp.example(def))
+		}
+		if def["x-constraint"] != nil {
+			for k, v := range def["x-constraint"].(map[string]interface{}) {
+				if k == "length" {
+					continue
+				}
+				cname := "x_constraint_" + k
+				if t.ArrayTypeDef != nil {
+					t.ArrayTypeDef.Annotations = addAnnotation(t.ArrayTypeDef.Annotations, cname, v)
+				} else if t.AliasTypeDef != nil {
+					t.AliasTypeDef.Annotations = addAnnotation(t.AliasTypeDef.Annotations, cname, v)
+				}
+			}
+		}
+	case "string":
+		if def["enum"] != nil && msEnum(def)["modelAsString"] != true {
+			tb := rdl.NewEnumTypeBuilder("Enum", name)
+			if !fromFieldSpec {
+				tb.Comment(getString(def, "description"))
+			}
+			seen := make(map[string]bool)
+			folded := make(map[string]string)
+			names := make(map[string]string)
+			for _, e := range enumValues(def) {
+				sym, ok := e.value.(string)
+				if !ok {
+					//the value is what goes on the wire, so it is kept as written
+					j, _ := json.Marshal(e.value)
+					sym = string(j)
+					imp.warn("enum value %s of a string is not a string, imported as %q", sym, sym)
+				}
+				if seen[sym] {
+					imp.warn("enum value %q is repeated, keeping only the first", sym)
+					continue
+				}
+				seen[sym] = true
+				if prev, ok := folded[strings.ToLower(sym)]; ok {
+					//distinct values, but they may collide in generated code
+					imp.warn("enum values %q and %q differ only in case", prev, sym)
+				} else {
+					folded[strings.ToLower(sym)] = sym
+				}
+				if e.name != "" && e.name != sym {
+					names[sym] = e.name
+				}
+				tb.Element(sym, e.description)
+			}
+			t = tb.Build()
+			for _, el := range t.EnumTypeDef.Elements {
+				if n, ok := names[string(el.Symbol)]; ok {
+					//the symbol is the value on the wire, so the name is only a hint
+					el.Annotations = addAnnotation(el.Annotations, "x_name", n)
+				}
+			}
+			break
+		}
+		if stringType(def) == "Bytes" {
+			//the lengths are of the encoded string: three bytes take four characters
+			tb := rdl.NewBytesTypeBuilder(name)
+			if !fromFieldSpec {
+				tb.Comment(getString(def, "description"))
+			}
+			enc := bytesEncoding(def)
+			if maxlen := getInt(def, "maxLength"); maxlen >= 0 && enc != "" {
+				tb.MaxSize(maxlen * 3 / 4)
+			}
+			t = tb.Build()
+			if enc != "" {
+				annotateType(t, "x_encoding", enc)
+			}
+			annotateType(t, "x_format", formatAnnotation(def))
+			annotateType(t, "x_minLength", def["minLength"])
+			annotateType(t, "x_maxLength", def["maxLength"])
+			if !fromFieldSpec {
+				annotateType(t, "x_example", imp.example(def))
+			}
+			break
+		}
+		if base := stringType(def); base != "String" && def["pattern"] == nil && def["minLength"] == nil && def["maxLength"] == nil {
+			tb := rdl.NewAliasTypeBuilder(base, name)
+			if !fromFieldSpec {
+				tb.Comment(getString(def, "description"))
+			}
+			t = tb.Build()
+			if isDate(def) {
+				t.AliasTypeDef.Annotations = addAnnotation(t.AliasTypeDef.Annotations, "x_format_date", true)
+			}
+			if imp.example(def) != nil && !fromFieldSpec {
+				t.AliasTypeDef.Annotations = addAnnotation(t.AliasTypeDef.Annotations, "x_example", imp.example(def))
+				imp.noteExample(name, imp.example(def))
+			}
+			break
+		}
+		tb := rdl.NewStringTypeBuilder(name)
+		if !fromFieldSpec {
+			tb.Comment(getString(def, "description"))
+		}
+		pat := getString(def, "pattern")
+		if pat != "" {
+			tb.Pattern(pat)
+		}
+		maxlen := getInt(def, "maxLength")
+		if maxlen >= 0 {
+			tb.MaxSize(maxlen)
+		}
+		minlen := getInt(def, "minLength")
+		if minlen >= 0 {
+			tb.MinSize(minlen)
+		}
+		if getString(def, "format") == "char" {
+			tb.MinSize(1).MaxSize(1)
+		}
+		length, err := constraintLength(name, def, "minLength", "maxLength")
+		if err != nil {
+			return imp.errorf("%v", err)
+		}
+		if length >= 0 {
+			tb.MinSize(length).MaxSize(length)
+		}
+		t = tb.Build()
+		if imp.example(def) != nil && !fromFieldSpec {
+			if t.StringTypeDef != nil {
+				t.StringTypeDef.Annotations = addAnnotation(t.StringTypeDef.Annotations, "x_example", imp.example(def))
+			} else if t.AliasTypeDef != nil {
+				t.AliasTypeDef.Annotations = addAnnotation(t.AliasTypeDef.Annotations, "x_example", imp.example(def))
+			}
+			imp.noteExample(name, imp.example(def))
+		}
+		annotateType(t, "x_format", formatAnnotation(def))
+		for k, v := range imp.stringBounds(def) {
+			annotateType(t, k, v)
+		}
+		if isChar(def) {
+			annotateType(t, "x_format_char", true)
+		}
+		if isJSON(def) {
+			annotateType(t, "x_format_json", true)
+		}
+		if isTime(def) {
+			annotateType(t, "x_format_time", true)
+		}
+		if def["enum"] != nil {
+			//an x-ms-enum modelled as a string, open to values not listed
+			var values []interface{}
+			for _, e := range enumValues(def) {
+				values = append(values, e.value)
+			}
+			annotateType(t, "x_values", values)
+		}
+		if xformat, ok := def["x-format"].(map[string]interface{}); ok {
+			for k, v := range xformat {
+				aname := "x_format_" + k
+				if t.StringTypeDef != nil {
+					t.StringTypeDef.Annotations = addAnnotation(t.StringTypeDef.Annotations, aname, v)
+				} else if t.AliasTypeDef != nil {
+					t.AliasTypeDef.Annotations = addAnnotation(t.AliasTypeDef.Annotations, aname, v)
+				}
+			}
+		}
+		if def["x-constraint"] != nil {
+			for k, v := range def["x-constraint"].(map[string]interface{}) {
+				if k == "length" {
+					continue
+				}
+				cname := "x_constraint_" + k
+				if t.StringTypeDef != nil {
+					t.StringTypeDef.Annotations = addAnnotation(t.StringTypeDef.Annotations, cname, v)
+				} else if t.AliasTypeDef != nil {
+					t.AliasTypeDef.Annotations = addAnnotation(t.AliasTypeDef.Annotations, cname, v)
+				}
+			}
+		}
+	case "integer":
+		base := imp.intType(def)
+		tb := rdl.NewNumberTypeBuilder(base, name)
+		if !fromFieldSpec {
+			tb.Comment(getString(def, "description"))
+		}
+		var unknown map[string]interface{}
+		var allowed []interface{}
+		if def["x-constraint"] != nil {
+			for k, v := range def["x-constraint"].(map[string]interface{}) {
+				switch k {
+				case "positive", "nonnegative":
+					if v == true {
+						tb.Min(intBound(base, 0))
+					}
+				case "negative":
+					if v == true {
+						tb.Max(intBound(base, -1))
+					}
+				case "range":
+					min, max, err := constraintRange(name, v)
+					if err != nil {
+						return imp.errorf("%v", err)
+					}
+					tb.Min(intBound(base, int64(min)))
+					tb.Max(intBound(base, int64(max)))
+				case "values", "enum":
+					allowed = imp.constraintValues(name, k, v, true)
+				default:
+					if unknown == nil {
+						unknown = make(map[string]interface{})
+					}
+					unknown[k] = v
+				}
+			}
+		}
+		if def["minimum"] != nil {
+			tb.Min(intBound(base, int64(getFloat(def, "minimum"))))
+		}
+		if def["maximum"] != nil {
+			tb.Max(intBound(base, int64(getFloat(def, "maximum"))))
+		}
+		t = tb.Build()
+		for k, v := range unknown {
+			t.NumberTypeDef.Annotations = addAnnotation(t.NumberTypeDef.Annotations, "x_constraint_"+k, v)
+		}
+		if allowed != nil {
+			t.NumberTypeDef.Annotations = addAnnotation(t.NumberTypeDef.Annotations, "x_values", allowed)
+		}
+		if imp.example(def) != nil && !fromFieldSpec {
+			t.NumberTypeDef.Annotations = addAnnotation(t.NumberTypeDef.Annotations, "x_example", imp.example(def))
+			imp.noteExample(name, imp.example(def))
+		}
+		t.NumberTypeDef.Annotations = addNumberAnnotations(t.NumberTypeDef.Annotations, def)
+	case "number":
+		tb := rdl.NewNumberTypeBuilder("Float64", name)
+		if !fromFieldSpec {
+			tb.Comment(getString(def, "description"))
+		}
+		var unknown map[string]interface{}
+		var values []interface{}
+		if def["x-constraint"] != nil {
+			for k, v := range def["x-constraint"].(map[string]interface{}) {
+				switch k {
+				case "positive", "nonnegative":
+					if v == true {
+						tb.Min(0.0)
+					}
+				case "negative":
+					if v == true {
+						tb.Max(0.0)
+					}
+				case "range":
+					min, max, err := constraintBounds(name, v)
+					if err != nil {
+						return imp.errorf("%v", err)
+					}
+					tb.Min(min)
+					tb.Max(max)
+				case "values", "enum":
+					values = imp.constraintValues(name, k, v, false)
+				default:
+					if unknown == nil {
+						unknown = make(map[string]interface{})
+					}
+					unknown[k] = v
+				}
+			}
+		}
+		if def["minimum"] != nil {
+			tb.Min(getFloat(def, "minimum"))
+		}
+		if def["maximum"] != nil {
+			tb.Max(getFloat(def, "maximum"))
+		}
+		t = tb.Build()
+		for k, v := range unknown {
+			t.NumberTypeDef.Annotations = addAnnotation(t.NumberTypeDef.Annotations, "x_constraint_"+k, v)
+		}
+		if values != nil {
+			t.NumberTypeDef.Annotations = addAnnotation(t.NumberTypeDef.Annotations, "x_values", values)
+		}
+		if imp.example(def) != nil && !fromFieldSpec {
+			t.NumberTypeDef.Annotations = addAnnotation(t.NumberTypeDef.Annotations, "x_example", imp.example(def))
+			imp.noteExample(name, imp.example(def))
+		}
+		t.NumberTypeDef.Annotations = addNumberAnnotations(t.NumberTypeDef.Annotations, def)
+	default:
+		if imp.opts.FailOnUnsupportedType {
+			return imp.errorf("unsupported top level type for %s: %v", name, def)
+		}
+		imp.drop("type", "unsupported top level type for %s: %v", name, def)
+	}
+	if t == nil {
+		return nil
+	}
+	if super != "" {
+		switch t.Variant {
+		case rdl.TypeVariantStringTypeDef:
+			t.StringTypeDef.Type = rdl.TypeRef(super)
+		case rdl.TypeVariantNumberTypeDef:
+			t.NumberTypeDef.Type = rdl.TypeRef(super)
+		}
+	}
+	if nullable {
+		annotateType(t, "x_nullable", true)
+	}
+	if scalarUnion && t.UnionTypeDef != nil {
+		annotateType(t, "x_scalar_union", true)
+	}
+	if docs, ok := def["externalDocs"].(map[string]interface{}); ok {
+		annotateType(t, "x_externalDocs", docs["url"])
+		annotateType(t, "x_externalDocs_description", docs["description"])
+	}
+	imp.sb.AddType(t)
+	return nil
+}
+
+// mergeAllOf combines the members of an allOf into a single object schema. When
+// the first member is the only $ref, the result extends the referenced type,
+// which is returned as the base. Otherwise every member, including referenced
+// definitions, is flattened into the result.
+func (imp *importer) mergeAllOf(name string, def swagger.Type) (swagger.Type, string, error) {
+	members, ok := def["allOf"].([]interface{})
+	if !ok {
+		return nil, "", imp.errorf("bad allOf for %s: %v", name, def["allOf"])
+	}
+	refs := 0
+	for _, m := range members {
+		if md, ok := m.(map[string]interface{}); ok && md["$ref"] != nil {
+			refs++
+		}
+	}
+	merged := make(swagger.Type)
+	for k, v := range def {
+		if k != "allOf" {
+			merged[k] = v
+		}
+	}
+	if isArrayAllOf(def, members) {
+		//the members describe an array between them, such as one giving the
+		//type and another the items, so merge them as they are
+		for _, m := range members {
+			for k, v := range m.(map[string]interface{}) {
+				merged[k] = v
+			}
+		}
+		merged["type"] = "array"
+		return merged, "", nil
+	}
+	merged["type"] = "object"
+	properties := make(map[string]interface{})
+	var required []interface{}
+	add := func(md map[string]interface{}) {
+		if props, ok := md["properties"].(map[string]interface{}); ok {
+			for k, v := range props {
+				properties[k] = v
+			}
+		}
+		if req, ok := md["required"].([]interface{}); ok {
+			required = append(required, req...)
+		}
+	}
+	add(def)
+	base := ""
+	for i, m := range members {
+		md, ok := m.(map[string]interface{})
+		if !ok {
+			return nil, "", imp.errorf("bad allOf member for %s: %v", name, m)
+		}
+		if ref, ok := refTypeName(getString(md, "$ref")); ok {
+			if target, ok := imp.doc.Definitions[ref]; ok && !isObjectSchema(target) {
+				//an array or enum has no properties to extend or merge
+				return nil, "", imp.errorf("the allOf of %s refers to %s, which is not an object", name, ref)
+			}
+			if i == 0 && refs == 1 {
+				base = camelize(ref, imp.acronyms)
+				continue
+			}
+			target, ok := imp.doc.Definitions[ref]
+			if !ok {
+				return nil, "", imp.errorf("unresolved allOf $ref for %s: %s", name, md["$ref"])
+			}
+			if target["allOf"] != nil {
+				flat, b, err := imp.mergeAllOf(ref, target)
+				if err != nil {
+					return nil, "", err
+				}
+				if b != "" {
+					//flattening this one loses its base too
+					if bdef, ok := imp.doc.Definitions[b]; ok {
+						add(bdef)
+					}
+				}
+				target = flat
+			}
+			imp.approximate("allOf", "the %s in the allOf of %s is flattened, not extended", ref, name)
+			md = target
+		}
+		add(md)
+	}
+	merged["properties"] = properties
+	if required != nil {
+		merged["required"] = required
+	}
+	return merged, base, nil
+}
+
+// isArrayAllOf returns true if a schema and the members of its allOf describe
+// an array: none refers to a definition or has properties, and at least one is
+// of type array while the rest have no type.
+func isArrayAllOf(def swagger.Type, members []interface{}) bool {
+	array := getString(def, "type") == "array"
+	for _, m := range members {
+		md, ok := m.(map[string]interface{})
+		if !ok || md["$ref"] != nil || md["properties"] != nil {
+			return false
+		}
+		switch getString(md, "type") {
+		case "array":
+			array = true
+		case "":
+		default:
+			return false
+		}
+	}
+	return array && def["properties"] == nil
+}
+
+// unwrapAllOfRef returns the schema as a plain $ref if it is an allOf of just a
+// $ref to a definition that is not an object, the usual way to give such a
+// $ref a description of its own. There is nothing to merge, so the schema is
+// the referenced type, rather than a struct extending it.
+func (imp *importer) unwrapAllOfRef(def swagger.Type) swagger.Type {
+	members, ok := def["allOf"].([]interface{})
+	if !ok || len(members) != 1 || def["properties"] != nil {
+		return def
+	}
+	md, ok := members[0].(map[string]interface{})
+	if !ok || len(md) != 1 {
+		return def
+	}
+	ref, ok := refTypeName(getString(md, "$ref"))
+	if !ok {
+		return def
+	}
+	if target, ok := imp.doc.Definitions[ref]; !ok || isObjectSchema(target) {
+		return def
+	}
+	unwrapped := make(swagger.Type, len(def))
+	for k, v := range def {
+		if k != "allOf" {
+			unwrapped[k] = v
+		}
+	}
+	unwrapped["$ref"] = md["$ref"]
+	return unwrapped
+}
+
+// stringBounds returns the minimum and maximum of a string schema, which only
+// apply to numbers, as x_minimum and x_maximum annotations, warning about them.
+// They are usually meant for a number written as a string.
+func (imp *importer) stringBounds(def swagger.Type) map[string]interface{} {
+	var bounds map[string]interface{}
+	for _, k := range []string{"minimum", "maximum"} {
+		if v, ok := def[k]; ok {
+			imp.warn("%s %v does not apply to a string, kept as x_%s", k, annotationValue(v), k)
+			if bounds == nil {
+				bounds = make(map[string]interface{})
+			}
+			bounds["x_"+k] = v
+		}
+	}
+	return bounds
+}
+
+// isObjectSchema returns true if the schema describes an object, or at least
+// not an array, enum, or scalar.
+func isObjectSchema(def swagger.Type) bool {
+	if def["enum"] != nil {
+		return false
+	}
+	switch getString(def, "type") {
+	case "object":
+		return true
+	case "":
+		return def["items"] == nil
+	}
+	return false
+}
+
+// msEnum returns the x-ms-enum extension of a schema, with which Azure specs
+// name an enum and its values, or nil if it has none.
+func msEnum(def swagger.Type) map[string]interface{} {
+	m, _ := def["x-ms-enum"].(map[string]interface{})
+	return m
+}
+
+// enumValue is a value of an enum, with the name and description that an
+// x-ms-enum may give it.
+type enumValue struct {
+	value       interface{}
+	name        string
+	description string
+}
+
+// enumValues returns the values of the schema's enum: those its x-ms-enum
+// lists, if any, which override the enum, otherwise those of the enum.
+func enumValues(def swagger.Type) []enumValue {
+	var values []enumValue
+	if ms, ok := msEnum(def)["values"].([]interface{}); ok {
+		for _, v := range ms {
+			if vm, ok := v.(map[string]interface{}); ok && vm["value"] != nil {
+				values = append(values, enumValue{vm["value"], getString(vm, "name"), getString(vm, "description")})
+			}
+		}
+		if len(values) > 0 {
+			return values
+		}
+	}
+	enum, _ := def["enum"].([]interface{})
+	for _, e := range enum {
+		values = append(values, enumValue{value: e})
+	}
+	return values
+}
+
+// enumKey identifies the enum a schema describes, for sharing the type
+// synthesized for it: its values, and its x-ms-enum, if any.
+func enumKey(def swagger.Type) string {
+	var j []byte
+	if ms := msEnum(def); ms != nil {
+		j, _ = json.Marshal([]interface{}{def["enum"], ms})
+	} else {
+		j, _ = json.Marshal(def["enum"])
+	}
+	return string(j)
+}
+
+// checkEnumDefault warns if a schema with an enum, of its own or by a $ref to
+// an enum definition, has a default that is not one of its values, mentioning
+// the value it differs from only in case, if any.
+func (imp *importer) checkEnumDefault(def swagger.Type, value interface{}) {
+	if ref, ok := refTypeName(getString(def, "$ref")); ok && imp.doc.Definitions[ref] != nil {
+		def = imp.doc.Definitions[ref]
+	}
+	enum, ok := def["enum"].([]interface{})
+	if !ok {
+		return
+	}
+	for _, e := range enum {
+		if e == value {
+			return
+		}
+	}
+	s, ok := value.(string)
+	if !ok {
+		imp.warn("default %s is not one of the enum values", annotationValue(value))
+		return
+	}
+	for _, e := range enum {
+		if es, ok := e.(string); ok && strings.EqualFold(es, s) {
+			imp.warn("default %q is not one of the enum values, which are case-sensitive: did you mean %q?", s, es)
+			return
+		}
+	}
+	imp.warn("default %q is not one of the enum values", s)
+}
+
+// definition returns the swagger definition imported as the named type, or nil.
+func (imp *importer) definition(tname string) swagger.Type {
+	for k, def := range imp.doc.Definitions {
+		if camelize(k, imp.acronyms) == tname {
+			return def
+		}
+	}
+	return nil
+}
+
+// definesProperty returns true if the object schema has the named property,
+// either directly or through the members of its allOf.
+func (imp *importer) definesProperty(def swagger.Type, fname string, visited map[string]bool) bool {
+	if def == nil {
+		return false
+	}
+	if props, ok := def["properties"].(map[string]interface{}); ok && props[fname] != nil {
+		return true
+	}
+	members, _ := def["allOf"].([]interface{})
+	for _, m := range members {
+		md, ok := m.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if ref, ok := refTypeName(getString(md, "$ref")); ok {
+			if visited == nil {
+				visited = make(map[string]bool)
+			}
+			if visited[ref] {
+				continue
+			}
+			visited[ref] = true
+			md = imp.doc.Definitions[ref]
+		}
+		if imp.definesProperty(md, fname, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitReadOnly gives each definition with readOnly or writeOnly properties a
+// request variant, named with a Request suffix, that has no readOnly
+// properties and refers to the request variants of other definitions. The
+// definition itself loses its writeOnly properties, and is what responses use.
+func (imp *importer) splitReadOnly() {
+	defs := imp.doc.Definitions
+	imp.variants = make(map[string]string)
+	for _, k := range sortedKeys(defs) {
+		props, _ := defs[k]["properties"].(map[string]interface{})
+		for _, pdef := range props {
+			if m, ok := pdef.(map[string]interface{}); ok && (m["readOnly"] == true || m["writeOnly"] == true) {
+				variant := k + "Request"
+				for i := 2; defs[variant] != nil; i++ {
+					variant = k + "Request" + strconv.Itoa(i)
+				}
+				imp.variants[k] = variant
+				break
+			}
+		}
+	}
+	for _, k := range sortedKeys(defs) {
+		variant, ok := imp.variants[k]
+		if !ok {
+			continue
+		}
+		imp.push(k)
+		for _, fname := range sortedProperties(defs[k]["properties"].(map[string]interface{})) {
+			pdef, _ := defs[k]["properties"].(map[string]interface{})[fname].(map[string]interface{})
+			if pdef["readOnly"] == true && pdef["writeOnly"] == true {
+				imp.warn("property %s is both readOnly and writeOnly, so it is kept in requests and responses", fname)
+			}
+		}
+		imp.pop()
+		defs[variant] = imp.requestSchema(withoutProperties(defs[k], "readOnly")).(map[string]interface{})
+		defs[k] = withoutProperties(defs[k], "writeOnly")
+	}
+}
+
+// withoutProperties returns a copy of the object schema without the properties
+// flagged with the given keyword, unless they are flagged both readOnly and
+// writeOnly, which is contradictory.
+func withoutProperties(def swagger.Type, flag string) swagger.Type {
+	props := def["properties"].(map[string]interface{})
+	kept := make(map[string]interface{})
+	for k, v := range props {
+		pdef, _ := v.(map[string]interface{})
+		if pdef[flag] == true && !(pdef["readOnly"] == true && pdef["writeOnly"] == true) {
+			continue
+		}
+		kept[k] = v
+	}
+	result := make(swagger.Type, len(def))
+	for k, v := range def {
+		result[k] = v
+	}
+	result["properties"] = kept
+	if required, ok := def["required"].([]interface{}); ok {
+		var req []interface{}
+		for _, r := range required {
+			if name, ok := r.(string); ok && kept[name] != nil {
+				req = append(req, r)
+			}
+		}
+		result["required"] = req
+	}
+	return result
+}
+
+// requestSchema returns a copy of the schema with every $ref to a split
+// definition replaced by a $ref to its request variant.
+func (imp *importer) requestSchema(v interface{}) interface{} {
+	switch s := v.(type) {
+	case swagger.Type:
+		return imp.requestSchema(map[string]interface{}(s))
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(s))
+		for k, e := range s {
+			m[k] = imp.requestSchema(e)
+		}
+		if ref, ok := refTypeName(getString(s, "$ref")); ok {
+			if variant, ok := imp.variants[ref]; ok {
+				m["$ref"] = definitionRef(variant)
+			}
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(s))
+		for i, e := range s {
+			a[i] = imp.requestSchema(e)
+		}
+		return a
+	}
+	return v
+}
+
+// definitionRef returns the $ref to the named definition, escaped as a JSON
+// pointer in a URI fragment, the reverse of refTypeName.
+func definitionRef(name string) string {
+	return "#/definitions/" + url.PathEscape(strings.NewReplacer("~", "~0", "/", "~1").Replace(name))
+}
+
+// inlineTypeName returns the name for a type synthesized from an inline object
+// schema: its title if it has one that makes a usable name not already taken,
+// otherwise the fallback derived from where the schema appears.
+func (imp *importer) inlineTypeName(def swagger.Type, fallback string) string {
+	if msName := getString(msEnum(def), "name"); msName != "" {
+		name := imp.capitalize(camelize(msName, imp.acronyms))
+		if isIdentifier(name) {
+			if imp.enums[enumKey(def)] == name {
+				//the same enum, already imported
+				return name
+			}
+			if !imp.typeNames[name] {
+				imp.typeNames[name] = true
+				return name
+			}
+			imp.approximate("x-ms-enum", "x-ms-enum name %q is already the name of a type, using %s", msName, fallback)
+			return fallback
+		}
+	}
+	title := getString(def, "title")
+	if title == "" || def["properties"] == nil {
+		return fallback
+	}
+	name := imp.capitalize(camelize(title, imp.acronyms))
+	if !isIdentifier(name) {
+		return fallback
+	}
+	if imp.typeNames[name] {
+		imp.approximate("title", "title %q is already the name of a type, using %s", title, fallback)
+		return fallback
+	}
+	imp.typeNames[name] = true
+	return name
+}
+
+// importElementType returns the type name for the items of an array or the
+// values of a map, synthesizing a type named ename when their schema needs
+// one, such as for constraints. Arrays whose items carry the same enum values
+// share a single enum type.
+func (imp *importer) importElementType(ename string, idef swagger.Type) (string, error) {
+	if !requiresTypeDef(idef) {
+		ftype, _ := imp.normalizeTypeName(idef)
+		if ftype == "" {
+			//an element schema without a type, such as {}, allows any value
+			ftype = "Any"
+		}
+		return ftype, nil
+	}
+	iname := imp.inlineTypeName(idef, ename)
+	if idef["enum"] != nil {
+		return imp.importInlineEnum(iname, idef)
+	}
+	err := imp.importSwaggerType(iname, idef, true)
+	return iname, err
+}
+
+// importInlineEnum synthesizes an enum type for an inline enum schema, reusing
+// the type already synthesized for an identical list of values. The elements
+// keep the order of the source array, so the same values in a different order
+// get a type of their own; and since schemas are visited in a fixed order, the
+// type shared by several schemas is always named after the same one of them.
+func (imp *importer) importInlineEnum(name string, def swagger.Type) (string, error) {
+	key := enumKey(def)
+	if tname, ok := imp.enums[key]; ok {
+		return tname, nil
+	}
+	imp.enums[key] = name
+	err := imp.importSwaggerType(name, def, true)
+	return name, err
+}
+
+// addNumberAnnotations records the numeric keywords that have no direct RDL
+// equivalent: the bounds RDL uses are always inclusive.
+func addNumberAnnotations(anno map[rdl.ExtendedAnnotation]string, def swagger.Type) map[rdl.ExtendedAnnotation]string {
+	anno = addAnnotation(anno, "x_multipleOf", def["multipleOf"])
+	if def["exclusiveMinimum"] == true {
+		anno = addAnnotation(anno, "x_exclusiveMinimum", true)
+	}
+	if def["exclusiveMaximum"] == true {
+		anno = addAnnotation(anno, "x_exclusiveMaximum", true)
+	}
+	return anno
+}
+
+// constraintValues returns the numbers in an x-constraint list of allowed
+// values, for an x_values annotation. Anything that is not a number, or for
+// an integer type is not a whole number, is left out with a warning.
+func (imp *importer) constraintValues(name string, key string, v interface{}, integer bool) []interface{} {
+	list, ok := v.([]interface{})
+	if !ok {
+		imp.drop("x-constraint", "x-constraint %s on %s is not a list: %v", key, name, v)
+		return nil
+	}
+	values := make([]interface{}, 0, len(list))
+	for _, e := range list {
+		n, ok := e.(float64)
+		switch {
+		case !ok:
+			imp.drop("x-constraint", "value %s in the x-constraint %s on %s is not a number", annotationValue(e), key, name)
+		case integer && n != math.Trunc(n):
+			imp.drop("x-constraint", "value %v in the x-constraint %s on %s is not an integer", e, key, name)
+		default:
+			values = append(values, e)
+		}
+	}
+	return values
+}
+
+// constraintRange returns the bounds of an integer x-constraint range, given
+// as a two element array such as [0, 100].
+func constraintRange(name string, v interface{}) (int32, int32, error) {
+	min, max, err := constraintBounds(name, v)
+	if err != nil || min != float64(int32(min)) || max != float64(int32(max)) {
+		return 0, 0, fmt.Errorf("bad x-constraint range for %s: %v", name, v)
+	}
+	return int32(min), int32(max), nil
+}
+
+// constraintBounds returns the bounds of a number x-constraint range, such as
+// [0, 0.5].
+func constraintBounds(name string, v interface{}) (float64, float64, error) {
+	bounds, ok := v.([]interface{})
+	if ok && len(bounds) == 2 {
+		min, ok1 := bounds[0].(float64)
+		max, ok2 := bounds[1].(float64)
+		if ok1 && ok2 && min <= max {
+			return min, max, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("bad x-constraint range for %s: %v", name, v)
+}
+
+// constraintLength returns the exact length required by an x-constraint
+// "length" entry, or -1 if there is none. It is an error for the length to
+// disagree with the schema's own min/max size keywords.
+func constraintLength(name string, def swagger.Type, minKey, maxKey string) (int32, error) {
+	xc, ok := def["x-constraint"].(map[string]interface{})
+	if !ok || xc["length"] == nil {
+		return -1, nil
+	}
+	length := getInt(xc, "length")
+	if length < 0 {
+		return -1, fmt.Errorf("bad x-constraint length for %s: %v", name, xc["length"])
+	}
+	for _, k := range []string{minKey, maxKey} {
+		if def[k] != nil && getInt(def, k) != length {
+			return -1, fmt.Errorf("x-constraint length %d for %s conflicts with %s %v", length, name, k, def[k])
+		}
+	}
+	return length, nil
+}
+
+// isMapLike returns true if the object schema describes a map, i.e. its
+// additionalProperties is a schema or true.
+func isMapLike(def swagger.Type) bool {
+	switch ap := def["additionalProperties"].(type) {
+	case bool:
+		return ap
+	case map[string]interface{}:
+		return true
+	}
+	return false
+}
+
+func (imp *importer) importSwaggerMapType(name string, def swagger.Type, fromFieldSpec bool) (*rdl.Type, error) {
+	tb := rdl.NewMapTypeBuilder("Map", name)
+	if !fromFieldSpec {
+		tb.Comment(getString(def, "description"))
+	}
+	keys, err := imp.mapKeyType(name, def)
+	if err != nil {
+		return nil, err
+	}
+	tb.Keys(keys)
+	items := "Any"
+	if idef, ok := def["additionalProperties"].(map[string]interface{}); ok {
+		imp.push("additionalProperties")
+		ftype, err := imp.importElementType(name+"_Value", idef)
+		if err != nil {
+			return nil, err
+		}
+		imp.pop()
+		if ftype != "" {
+			items = ftype
+		}
+	}
+	tb.Items(items)
+	t := tb.Build()
+	if def["minProperties"] != nil {
+		t.MapTypeDef.Annotations = addAnnotation(t.MapTypeDef.Annotations, "x_minProperties", def["minProperties"])
+	}
+	if def["maxProperties"] != nil {
+		t.MapTypeDef.Annotations = addAnnotation(t.MapTypeDef.Annotations, "x_maxProperties", def["maxProperties"])
+	}
+	if imp.example(def) != nil && !fromFieldSpec {
+		t.MapTypeDef.Annotations = addAnnotation(t.MapTypeDef.Annotations, "x_example", imp.example(def))
+		imp.noteExample(name, imp.example(def))
+	}
+	return t, nil
+}
+
+// mapKeyType returns the key type for a map schema. JSON object keys are
+// always strings on the wire, but an x-key-type extension can say what the
+// strings encode, e.g. "UUID", or name a definition to use.
+func (imp *importer) mapKeyType(name string, def swagger.Type) (string, error) {
+	xkt, ok := def["x-key-type"]
+	if !ok {
+		return "String", nil
+	}
+	kt, _ := xkt.(string)
+	switch strings.ToLower(kt) {
+	case "string":
+		return "String", nil
+	case "uuid":
+		return "UUID", nil
+	case "symbol":
+		return "Symbol", nil
+	case "timestamp":
+		return "Timestamp", nil
+	case "integer", "int32":
+		return "Int32", nil
+	case "int64":
+		return "Int64", nil
+	case "bool", "boolean", "float32", "float64", "number", "bytes", "struct", "array", "map", "any", "union", "enum":
+		return "", fmt.Errorf("bad x-key-type for %s: %v is not a valid map key type", name, xkt)
+	}
+	if !isIdentifier(kt) {
+		return "", fmt.Errorf("bad x-key-type for %s: %v", name, xkt)
+	}
+	kt = camelize(kt, nil)
+	if !imp.typeNames[kt] {
+		return "", fmt.Errorf("bad x-key-type for %s: %s is not defined", name, kt)
+	}
+	return kt, nil
+}
+
+// normalizeFieldNames renames the struct's fields to the FieldCase style,
+// keeping each renamed field's wire name in an x_json_name annotation, which is
+// what the Go generators read for the JSON name (x_name is the name of an
+// x-ms-enum element). A field whose new name is not an identifier, or is taken
+// by another field, keeps its name.
+func (imp *importer) normalizeFieldNames(td *rdl.StructTypeDef) {
+	taken := make(map[rdl.Identifier]bool)
+	for _, f := range td.Fields {
+		taken[f.Name] = true
+	}
+	for _, f := range td.Fields {
+		name := fieldCase(string(f.Name), imp.opts.FieldCase, imp.acronyms)
+		if name == string(f.Name) || !isIdentifier(name) {
+			continue
+		}
+		if taken[rdl.Identifier(name)] {
+			imp.push(string(f.Name))
+			imp.warn("cannot rename field to %s, which another field already has", name)
+			imp.pop()
+			continue
+		}
+		taken[rdl.Identifier(name)] = true
+		f.Annotations = addAnnotation(f.Annotations, "x_json_name", string(f.Name))
+		f.Name = rdl.Identifier(name)
+	}
+}
+
+// fieldCase returns the name in camel (fooBar) or snake (foo_bar) case. A name
+// already in that case is returned unchanged, acronyms and all. Otherwise, in
+// camel case, the words after the first that are known acronyms are rendered
+// as such, as in userID.
+func fieldCase(name string, style string, acronyms map[string]string) string {
+	var words []string
+	start := 0
+	runes := []rune(name)
+	for i := 1; i <= len(runes); i++ {
+		split := i == len(runes) || runes[i] == '_' || runes[i] == '-' || runes[i] == ' '
+		if !split && unicode.IsUpper(runes[i]) {
+			//a new word starts at an upper case letter after a lower case one, or at the
+			//last of a run of upper case letters that a lower case one follows, as in HTTPServer
+			split = unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsLower(runes[i+1]))
+		}
+		if split {
+			if w := strings.Trim(string(runes[start:i]), "_- "); w != "" {
+				words = append(words, w)
+			}
+			start = i
+		}
+	}
+	if len(words) == 0 {
+		return name
+	}
+	switch style {
+	case "camel":
+		if !strings.ContainsAny(name, "_- ") && unicode.IsLower(runes[0]) {
+			return name
+		}
+		s := strings.ToLower(words[0])
+		for _, w := range words[1:] {
+			s += capitalizeWord(strings.ToLower(w), acronyms)
+		}
+		return s
+	case "snake":
+		if strings.ToLower(name) == name && !strings.ContainsAny(name, "- ") {
+			return name
+		}
+		for i, w := range words {
+			words[i] = strings.ToLower(w)
+		}
+		return strings.Join(words, "_")
+	}
+	return name
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9') {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// importSwaggerUnionType builds a union from a JSON Schema type array such
+// as ["integer", "string"]. Null has already been removed by resolveNullable.
+func (imp *importer) importSwaggerUnionType(name string, def swagger.Type, fromFieldSpec bool) *rdl.Type {
+	tb := rdl.NewUnionTypeBuilder("Union", name)
+	if !fromFieldSpec {
+		tb.Comment(getString(def, "description"))
+	}
+	types, _ := schemaTypes(def)
+	for _, st := range types {
+		if st == "integer" {
+			tb.Variant(imp.intType(def))
+		} else {
+			tb.Variant(canonicalTypeName(st))
+		}
+	}
+	return tb.Build()
+}
+
+// scalarUnionTypes returns the types of a schema whose value may be one of
+// several scalar types: an int-or-string, given by that format or by the
+// x-kubernetes-int-or-string extension, or a oneOf or anyOf of plain scalar
+// types.
+func scalarUnionTypes(def swagger.Type) ([]interface{}, bool) {
+	if getString(def, "format") == "int-or-string" || def["x-kubernetes-int-or-string"] == true {
+		return []interface{}{"integer", "string"}, true
+	}
+	members, ok := def["oneOf"].([]interface{})
+	if !ok {
+		members, ok = def["anyOf"].([]interface{})
+	}
+	if !ok || len(members) < 2 {
+		return nil, false
+	}
+	var types []interface{}
+	seen := make(map[string]bool)
+	for _, m := range members {
+		md, ok := m.(map[string]interface{})
+		if !ok || md["$ref"] != nil {
+			return nil, false
+		}
+		switch st := getString(md, "type"); st {
+		case "string", "integer", "number", "boolean", "null":
+			if !seen[st] {
+				seen[st] = true
+				types = append(types, st)
+			}
+		default:
+			return nil, false
+		}
+	}
+	return types, true
+}
+
+// schemaTypes returns the non-null type names of a schema, whose type may be
+// a single name or an array of names, and whether null is one of them.
+func schemaTypes(def map[string]interface{}) ([]string, bool) {
+	var types []string
+	nullable := false
+	switch st := def["type"].(type) {
+	case string:
+		types = append(types, st)
+	case []interface{}:
+		for _, o := range st {
+			if s, ok := o.(string); ok {
+				if s == "null" {
+					nullable = true
+				} else {
+					types = append(types, s)
+				}
+			}
+		}
+	}
+	return types, nullable
+}
+
+// normalizeSubschemas rewrites, in place, what JSON Schema allows in the
+// schemas of the document but swagger does not, so that the rest of the
+// importer sees only schema objects: see normalizeSchema.
+func (imp *importer) normalizeSubschemas(doc *swagger.Doc) {
+	imp.push("definitions")
+	for _, k := range sortedKeys(doc.Definitions) {
+		imp.push(k)
+		imp.normalizeSchema(doc.Definitions[k])
+		imp.pop()
+	}
+	imp.pop()
+	paths := make([]string, 0, len(doc.Paths))
+	for k := range doc.Paths {
+		paths = append(paths, k)
+	}
+	sort.Strings(paths)
+	imp.push("paths")
+	for _, path := range paths {
+		item := doc.Paths[path]
+		imp.push(path)
+		for _, param := range item.Parameters {
+			imp.push("parameters")
+			imp.push(param.Name)
+			imp.normalizeSchema(param.Schema)
+			imp.pop()
+			imp.pop()
+		}
+		for _, o := range []struct {
+			method string
+			op     *swagger.Operation
+		}{{"get", item.Get}, {"put", item.Put}, {"post", item.Post}, {"delete", item.Delete}, {"options", item.Options}, {"head", item.Head}, {"patch", item.Patch}} {
+			if o.op == nil {
+				continue
+			}
+			imp.push(o.method)
+			for _, param := range o.op.Parameters {
+				imp.push("parameters")
+				imp.push(param.Name)
+				imp.normalizeSchema(param.Schema)
+				imp.pop()
+				imp.pop()
+			}
+			for code, resp := range o.op.Responses {
+				if resp != nil {
+					imp.push("responses")
+					imp.push(code)
+					imp.normalizeSchema(resp.Schema)
+					imp.pop()
+					imp.pop()
+				}
+			}
+			imp.pop()
+		}
+		imp.pop()
+	}
+	imp.pop()
+}
+
+// normalizeSchema rewrites the subschemas of a schema, in place, that are not
+// schema objects. A boolean subschema true allows anything, and becomes an
+// empty schema, of type Any. A false property or allOf, anyOf, or oneOf
+// member allows nothing, and is dropped with a warning; false items, which
+// allow only an empty array, and tuple items, an array of schemas, are both
+// imported as items of any type, also with a warning.
+func (imp *importer) normalizeSchema(def map[string]interface{}) {
+	if def == nil {
+		return
+	}
+	if props, ok := def["properties"].(map[string]interface{}); ok {
+		for _, fname := range sortedProperties(props) {
+			imp.push(fname)
+			switch p := props[fname].(type) {
+			case bool:
+				if p {
+					props[fname] = map[string]interface{}{}
+				} else {
+					imp.encounter()
+					imp.drop("properties", "property %s is false, which allows no value", fname)
+					delete(props, fname)
+				}
+			case map[string]interface{}:
+				imp.normalizeSchema(p)
+			}
+			imp.pop()
+		}
+	}
+	imp.push("items")
+	switch items := def["items"].(type) {
+	case bool:
+		if !items {
+			imp.approximate("items", "items false allows only an empty array, imported as an array of Any")
+		}
+		def["items"] = map[string]interface{}{}
+	case []interface{}:
+		imp.approximate("items", "tuple items are not supported, imported as an array of Any")
+		def["items"] = map[string]interface{}{}
+	case map[string]interface{}:
+		imp.normalizeSchema(items)
+	}
+	imp.pop()
+	if values, ok := def["additionalProperties"].(map[string]interface{}); ok {
+		imp.push("additionalProperties")
+		imp.normalizeSchema(values)
+		imp.pop()
+	}
+	for _, key := range []string{"allOf", "anyOf", "oneOf"} {
+		members, ok := def[key].([]interface{})
+		if !ok {
+			continue
+		}
+		imp.push(key)
+		kept := members[:0]
+		for _, m := range members {
+			switch ms := m.(type) {
+			case bool:
+				if ms {
+					kept = append(kept, map[string]interface{}{})
+				} else {
+					imp.drop(key, "a false member of %s, which allows no value, is dropped", key)
+				}
+				continue
+			case map[string]interface{}:
+				imp.normalizeSchema(ms)
+			}
+			kept = append(kept, m)
+		}
+		def[key] = kept
+		imp.pop()
+	}
+}
+
+// resolveNullable rewrites a schema whose type is an array such as
+// ["string", "null"] into one with the single non-null type, reporting
+// whether null was allowed. A schema with several non-null types is left
+// with its type array for the caller to handle as a union. A null among the
+// enum values also makes the schema nullable, and is removed from them.
+func resolveNullable(def map[string]interface{}) (map[string]interface{}, bool) {
+	var rdef map[string]interface{}
+	rewrite := func() {
+		if rdef == nil {
+			rdef = make(map[string]interface{}, len(def))
+			for k, v := range def {
+				rdef[k] = v
+			}
+		}
+	}
+	nullable := false
+	if _, ok := def["type"].([]interface{}); ok {
+		var types []string
+		types, nullable = schemaTypes(def)
+		if len(types) <= 1 {
+			rewrite()
+			delete(rdef, "type")
+			if len(types) == 1 {
+				rdef["type"] = types[0]
+			}
+		}
+	}
+	if enum, ok := def["enum"].([]interface{}); ok {
+		values := make([]interface{}, 0, len(enum))
+		for _, v := range enum {
+			if v != nil {
+				values = append(values, v)
+			}
+		}
+		if len(values) < len(enum) {
+			nullable = true
+			rewrite()
+			if len(values) == 0 {
+				//only null was allowed, which leaves no enum at all
+				delete(rdef, "enum")
+			} else {
+				rdef["enum"] = values
+			}
+		}
+	}
+	if rdef == nil {
+		return def, nullable
+	}
+	return rdef, nullable
+}
+
+// annotateType adds an annotation to whichever kind of type definition t is.
+func annotateType(t *rdl.Type, name string, value interface{}) {
+	switch t.Variant {
+	case rdl.TypeVariantStructTypeDef:
+		t.StructTypeDef.Annotations = addAnnotation(t.StructTypeDef.Annotations, name, value)
+	case rdl.TypeVariantMapTypeDef:
+		t.MapTypeDef.Annotations = addAnnotation(t.MapTypeDef.Annotations, name, value)
+	case rdl.TypeVariantArrayTypeDef:
+		t.ArrayTypeDef.Annotations = addAnnotation(t.ArrayTypeDef.Annotations, name, value)
+	case rdl.TypeVariantEnumTypeDef:
+		t.EnumTypeDef.Annotations = addAnnotation(t.EnumTypeDef.Annotations, name, value)
+	case rdl.TypeVariantUnionTypeDef:
+		t.UnionTypeDef.Annotations = addAnnotation(t.UnionTypeDef.Annotations, name, value)
+	case rdl.TypeVariantStringTypeDef:
+		t.StringTypeDef.Annotations = addAnnotation(t.StringTypeDef.Annotations, name, value)
+	case rdl.TypeVariantBytesTypeDef:
+		t.BytesTypeDef.Annotations = addAnnotation(t.BytesTypeDef.Annotations, name, value)
+	case rdl.TypeVariantNumberTypeDef:
+		t.NumberTypeDef.Annotations = addAnnotation(t.NumberTypeDef.Annotations, name, value)
+	case rdl.TypeVariantAliasTypeDef:
+		t.AliasTypeDef.Annotations = addAnnotation(t.AliasTypeDef.Annotations, name, value)
+	}
+}
+
+// intType returns the RDL type for an integer schema: Int32 or Int64 as its
+// format says, or the default integer type if it has neither.
+func (imp *importer) intType(def swagger.Type) string {
+	switch getString(def, "format") {
+	case "int32":
+		return "Int32"
+	case "int64":
+		return "Int64"
+	}
+	if imp.opts.DefaultInt == "int64" {
+		return "Int64"
+	}
+	return "Int32"
+}
+
+// intBound returns n as a bound for an integer type with the given base, so
+// that the bound's number variant matches the type.
+func intBound(base string, n int64) interface{} {
+	if base == "Int64" {
+		return n
+	}
+	return int32(n)
+}
+
+// stringFormat describes how strings of a known format are imported: as which
+// RDL type, and whether the format is kept in an x_format annotation. The
+// formats with RDL equivalents need no annotation, nor do those that get
+// annotations of their own, such as x_format_date and x_encoding.
+type stringFormat struct {
+	rdlType  string
+	annotate bool
+}
+
+// stringFormats is the registry of known string formats. Strings of any
+// other format are imported as String, with the format kept verbatim in
+// x_format. RDL has no date-only type, so a date is a Timestamp, annotated
+// x_format_date wherever the importer can say so. Nor has it a time-of-day
+// type, and a time may have an offset that a Timestamp would lose, so a time
+// is a String annotated x_format_time.
+var stringFormats = map[string]stringFormat{
+	"uuid":          {"UUID", false},
+	"date-time":     {"Timestamp", false},
+	"date":          {"Timestamp", false},
+	"byte":          {"Bytes", false},
+	"base64url":     {"Bytes", false},
+	"binary":        {"Bytes", true},
+	"char":          {"String", false},
+	"json":          {"String", false},
+	"time":          {"String", false},
+	"uri":           {"String", true},
+	"uri-reference": {"String", true},
+	"uri-template":  {"String", true},
+	"url":           {"String", true},
+	"iri":           {"String", true},
+	"iri-reference": {"String", true},
+	"email":         {"String", true},
+	"idn-email":     {"String", true},
+	"hostname":      {"String", true},
+	"idn-hostname":  {"String", true},
+	"ipv4":          {"String", true},
+	"ipv6":          {"String", true},
+	"duration":      {"String", true},
+	"password":      {"String", true},
+	"decimal":       {"String", true},
+}
+
+// stringType returns the RDL type for a string schema, according to its format.
+func stringType(def swagger.Type) string {
+	if f, ok := stringFormats[getString(def, "format")]; ok {
+		return f.rdlType
+	}
+	return "String"
+}
+
+// formatAnnotation returns the format of a string schema to keep in an
+// x_format annotation, or nil if there is none to keep.
+func formatAnnotation(def swagger.Type) interface{} {
+	format := getString(def, "format")
+	if format == "" {
+		return nil
+	}
+	if f, ok := stringFormats[format]; ok && !f.annotate {
+		return nil
+	}
+	return format
+}
+
+// bytesEncoding returns how a string schema imported as Bytes encodes them:
+// "base64" for format byte, or "base64url".
+func bytesEncoding(def swagger.Type) string {
+	switch getString(def, "format") {
+	case "byte":
+		return "base64"
+	case "base64url":
+		return "base64url"
+	}
+	return ""
+}
+
+// isDate returns true if the string schema has format date.
+func isDate(def swagger.Type) bool {
+	return getString(def, "format") == "date"
+}
+
+// fieldDefault returns the default value for a field. A date default has no
+// time component, so it is given midnight UTC to make it a valid Timestamp.
+func fieldDefault(fdef swagger.Type) interface{} {
+	if s, ok := fdef["default"].(string); ok && isDate(fdef) {
+		if d, err := time.Parse("2006-01-02", s); err == nil {
+			return rdl.NewTimestamp(d).String()
+		}
+	}
+	return fdef["default"]
+}
+
+// durationPattern matches an ISO 8601 duration such as P1DT12H or PT0.5S.
+var durationPattern = regexp.MustCompile(`^P(\d+(\.\d+)?Y)?(\d+(\.\d+)?M)?(\d+(\.\d+)?W)?(\d+(\.\d+)?D)?(T(\d+(\.\d+)?H)?(\d+(\.\d+)?M)?(\d+(\.\d+)?S)?)?$`)
+
+// isDuration returns true if s is an ISO 8601 duration with at least one part.
+func isDuration(s string) bool {
+	return durationPattern.MatchString(s) && s != "P" && !strings.HasSuffix(s, "T")
+}
+
+// timePattern matches an RFC 3339 time, such as 08:30:00, 08:30:00.5Z or
+// 08:30:00+02:00, with or without the offset.
+var timePattern = regexp.MustCompile(`^\d{2}:\d{2}:\d{2}(\.\d+)?([Zz]|[+-]\d{2}:\d{2})?$`)
+
+// isTime returns true for a string schema holding a time of day.
+func isTime(def swagger.Type) bool {
+	return getString(def, "type") == "string" && getString(def, "format") == "time"
+}
+
+// isChar returns true for a string schema holding exactly one character:
+// either format char, or both min and max length of 1. A maxLength of 1 on
+// its own still allows the empty string, so it is not a char.
+func isChar(def swagger.Type) bool {
+	if getString(def, "format") == "char" {
+		return true
+	}
+	return getInt(def, "minLength") == 1 && getInt(def, "maxLength") == 1
+}
+
+// isJSON returns true for a string schema holding serialized JSON.
+func isJSON(def swagger.Type) bool {
+	return getString(def, "type") == "string" && (getString(def, "format") == "json" || getString(def, "x-format") == "json")
+}
+
+// refConstraintKeys are the keywords that, alongside a $ref to a string or
+// number definition, narrow it.
+var refConstraintKeys = []string{"minLength", "maxLength", "pattern", "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf", "x-constraint"}
+
+// hasRefConstraints returns true if the schema constrains the type it refers to.
+func hasRefConstraints(def swagger.Type) bool {
+	for _, k := range refConstraintKeys {
+		if def[k] != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// refConstraints returns a schema of just the constraints, and documentation,
+// given alongside a $ref.
+func refConstraints(def swagger.Type) swagger.Type {
+	c := make(swagger.Type)
+	for _, k := range append(refConstraintKeys, "description", "example") {
+		if v, ok := def[k]; ok {
+			c[k] = v
+		}
+	}
+	return c
+}
+
+// scalarDefinition returns the named definition, following any $refs, if it
+// is imported as a String or number type that can be derived from, otherwise
+// nil.
+func (imp *importer) scalarDefinition(ref string, depth int) swagger.Type {
+	def, ok := imp.doc.Definitions[ref]
+	if !ok || depth > imp.maxDepth() {
+		return nil
+	}
+	if next, ok := refTypeName(getString(def, "$ref")); ok {
+		return imp.scalarDefinition(next, depth+1)
+	}
+	if def["enum"] != nil {
+		return nil
+	}
+	switch getString(def, "type") {
+	case "string":
+		if stringType(def) == "String" && !isChar(def) {
+			return def
+		}
+	case "integer", "number":
+		return def
+	}
+	return nil
+}
+
+func requiresTypeDef(fdef swagger.Type) bool {
+	if fdef["$ref"] != nil {
+		//the referenced type is used as is, unless constrained further
+		return hasRefConstraints(fdef)
+	}
+	if fdef["properties"] != nil || fdef["allOf"] != nil {
+		return true
+	}
+	if fdef["pattern"] != nil || fdef["x-constraint"] != nil {
+		return true
+	}
+	if _, ok := fdef["x-format"].(map[string]interface{}); ok {
+		return true
+	}
+	if fdef["maxLength"] != nil || fdef["maximum"] != nil || fdef["minLength"] != nil || fdef["minimum"] != nil {
+		return true
+	}
+	if fdef["multipleOf"] != nil || fdef["exclusiveMinimum"] == true || fdef["exclusiveMaximum"] == true {
+		return true
+	}
+	if fdef["minItems"] != nil || fdef["maxItems"] != nil {
+		return true
+	}
+	if fdef["minProperties"] != nil || fdef["maxProperties"] != nil || fdef["x-key-type"] != nil {
+		return true
+	}
+	if fdef["enum"] != nil {
+		return true
+	}
+	if types, _ := schemaTypes(fdef); len(types) > 1 {
+		return true
+	}
+	if _, ok := scalarUnionTypes(fdef); ok {
+		return true
+	}
+	if items, ok := fdef["items"].(map[string]interface{}); ok && requiresTypeDef(items) {
+		return true
+	}
+	if values, ok := fdef["additionalProperties"].(map[string]interface{}); ok && requiresTypeDef(values) {
+		return true
+	}
+	//oneOf -> values
+	return false
+}
+
+func addAnnotation(anno map[rdl.ExtendedAnnotation]string, name string, value interface{}) map[rdl.ExtendedAnnotation]string {
+	if value == nil {
+		return anno
+	}
+	if anno == nil {
+		anno = make(map[rdl.ExtendedAnnotation]string)
+	}
+	anno[rdl.ExtendedAnnotation(name)] = annotationValue(value)
+	return anno
+}
+
+// annotationValue returns the string form of a value for an annotation. Objects
+// and arrays are encoded as JSON, so that they can be read back; scalars are
+// kept as plain strings.
+func annotationValue(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		j, err := json.Marshal(value)
+		if err == nil {
+			return string(j)
+		}
+	}
+	return fmt.Sprint(value)
+}
+
+func canonicalTypeName(tname string) string {
+	switch tname {
+	case "string":
+		return "String"
+	case "integer":
+		return "Int32"
+	case "number":
+		return "Float64"
+	case "boolean":
+		return "Bool"
+	case "object":
+		return "Struct"
+	case "array":
+		return "Array"
+	default:
+		return tname
+	}
+}
+
+//func normalizeTypeName(fdef swagger.Type) (string, string) {
+func (imp *importer) normalizeTypeName(fdef map[string]interface{}) (string, string) {
+	fdef, _ = resolveNullable(fdef)
+	fbase := "any"
+	ftype := ""
+	switch fdef["type"] {
+	case "string":
+		fbase = "String"
+		ftype = stringType(fdef)
+	case "integer":
+		fbase = imp.intType(fdef)
+		ftype = fbase
+	case "number":
+		fbase = "Float32"
+		ftype = fbase
+	case "boolean":
+		fbase = "Bool"
+		ftype = fbase
+	case "object":
+		fbase = "Struct"
+		ftype = fbase
+	case "array":
+		fbase = "Array"
+		ftype = fbase
+	}
+	if name, ok := refTypeName(getString(fdef, "$ref")); ok {
+		//the $ref takes precedence over the type
+		ftype = name
+	}
+	ftype = camelize(ftype, imp.acronyms)
+	return ftype, fbase
+}
+
+// refTypeName returns the definition name a local $ref points to. The ref is a
+// URI fragment holding a JSON Pointer, so it is percent-decoded and then has
+// its pointer escapes (~1 for '/', ~0 for '~') undone.
+func refTypeName(ref string) (string, bool) {
+	const prefix = "#/definitions/"
+	if !strings.HasPrefix(ref, prefix) {
+		return "", false
+	}
+	name, err := url.PathUnescape(ref[len(prefix):])
+	if err != nil {
+		name = ref[len(prefix):]
+	}
+	name = strings.Replace(name, "~1", "/", -1)
+	name = strings.Replace(name, "~0", "~", -1)
+	return name, true
+}
+
+func capitalize(text string) string {
+	return strings.ToUpper(text[0:1]) + text[1:]
+}
+
+// capitalizeWord capitalizes a word, unless it is one of the acronyms, keyed
+// by their lower case, which is rendered as given, e.g. "id" as "ID".
+func capitalizeWord(word string, acronyms map[string]string) string {
+	if a, ok := acronyms[strings.ToLower(word)]; ok {
+		return a
+	}
+	return capitalize(word)
+}
+
+// capitalize capitalizes a word of a name the importer makes up, taking the
+// Acronyms into account.
+func (imp *importer) capitalize(word string) string {
+	return capitalizeWord(word, imp.acronyms)
+}
+
+// camelize returns the name of the type for a swagger type or definition. A
+// name of several words, separated by spaces or slashes, has them joined in
+// camel case, with any of the acronyms rendered as such.
+func camelize(raw string, acronyms map[string]string) string {
+	switch raw {
+	case "string":
+		return "String"
+	case "integer":
+		return "Int32"
+	case "number":
+		return "Float64"
+	case "array":
+		return "Array"
+	case "object":
+		return "Struct"
+	}
+	lst := strings.FieldsFunc(raw, func(c rune) bool {
+		return c == ' ' || c == '/'
+	})
+	if len(lst) == 0 {
+		return raw
+	}
+	if len(lst) == 1 {
+		return lst[0]
+	}
+	s := capitalizeWord(lst[0], acronyms)
+	for _, ss := range lst[1:] {
+		s = s + capitalizeWord(ss, acronyms)
+	}
+	return s
+}
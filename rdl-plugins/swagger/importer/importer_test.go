@@ -0,0 +1,2362 @@
+package importer
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+// TestFixtures converts every swagger document in testdata, checks that the
+// schema survives a round trip through BuildParanoid, and compares it with
+// testdata/golden/<name>.json. A fixture expected to fail has the text of the
+// error in testdata/golden/<name>.err instead.
+func TestFixtures(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no fixtures in testdata")
+	}
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".json")
+		t.Run(name, func(t *testing.T) {
+			data, err := ioutil.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			schema, err := Convert(name, data, Options{})
+			errFile := filepath.Join("testdata", "golden", name+".err")
+			if want, rerr := ioutil.ReadFile(errFile); rerr == nil {
+				if err == nil {
+					t.Fatalf("converted, but expected an error containing %q", strings.TrimSpace(string(want)))
+				}
+				if !strings.Contains(err.Error(), strings.TrimSpace(string(want))) {
+					t.Fatalf("error %q does not contain %q", err, strings.TrimSpace(string(want)))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := rebuild(schema); err != nil {
+				t.Fatalf("the converted schema does not rebuild: %v", err)
+			}
+			checkGolden(t, filepath.Join("testdata", "golden", name+".json"), pretty(schema)+"\n")
+		})
+	}
+}
+
+// rebuild runs the schema's types and resources through BuildParanoid again,
+// catching anything the transforms applied after the first build broke.
+func rebuild(schema *rdl.Schema) error {
+	sb := rdl.NewSchemaBuilder(string(schema.Name))
+	for _, t := range schema.Types {
+		sb.AddType(t)
+	}
+	for _, r := range schema.Resources {
+		sb.AddResource(r)
+	}
+	_, err := sb.BuildParanoid()
+	return err
+}
+
+// pretty returns the schema as indented JSON, as the command line writes it.
+func pretty(schema *rdl.Schema) string {
+	j, _ := json.MarshalIndent(schema, "", "    ")
+	return string(j)
+}
+
+// checkGolden compares the output with the golden file, or rewrites the file
+// with -update.
+func checkGolden(t *testing.T, path string, got string) {
+	t.Helper()
+	if *update {
+		if err := ioutil.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		t.Fatalf("no golden file %s, run go test -update to create it", path)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Errorf("output differs from %s, run go test -update to accept it:\n%s", path, got)
+	}
+}
+
+//
+// Helpers for the tests of individual features.
+//
+
+// swaggerDoc returns a swagger document with the given paths and definitions,
+// each a JSON object.
+func swaggerDoc(paths string, definitions string) string {
+	return fmt.Sprintf(`{"swagger":"2.0","info":{"title":"test","version":"1"},"paths":%s,"definitions":%s}`, paths, definitions)
+}
+
+// convertDoc converts the document, failing the test on an error.
+func convertDoc(t *testing.T, doc string, opts Options) (*rdl.Schema, *Report) {
+	t.Helper()
+	schema, rep, err := ConvertWithReport("test", []byte(doc), opts)
+	if err != nil {
+		t.Fatalf("cannot convert: %v", err)
+	}
+	return schema, rep
+}
+
+// compact returns the value as compact JSON.
+func compact(v interface{}) string {
+	j, _ := json.Marshal(v)
+	return string(j)
+}
+
+// typeJSON returns the JSON of the named type in the schema, or "" if it has
+// no such type.
+func typeJSON(schema *rdl.Schema, name string) string {
+	for _, t := range schema.Types {
+		if n, _, _ := rdl.TypeInfo(t); string(n) == name {
+			return compact(t)
+		}
+	}
+	return ""
+}
+
+// resourceJSON returns the JSON of the resource for the method and path, or ""
+// if the schema has no such resource.
+func resourceJSON(schema *rdl.Schema, method string, path string) string {
+	for _, r := range schema.Resources {
+		if r.Method == method && r.Path == path {
+			return compact(r)
+		}
+	}
+	return ""
+}
+
+// typeNames returns the names of the schema's types, in order.
+func typeNames(schema *rdl.Schema) []string {
+	var names []string
+	for _, t := range schema.Types {
+		n, _, _ := rdl.TypeInfo(t)
+		names = append(names, string(n))
+	}
+	return names
+}
+
+// importCase is a test of importing some definitions, and optionally paths:
+// the JSON of the types and resources they should import as, keyed by type
+// name and by method and path, e.g. "GET /users", with "" for one that should
+// not exist, and the text of the warning they should give, if any.
+type importCase struct {
+	definitions string
+	paths       string
+	types       map[string]string
+	resources   map[string]string
+	warning     string
+}
+
+// checkImport converts each case with the options and checks the types,
+// resources and warnings that result.
+func checkImport(t *testing.T, opts Options, tests []importCase) {
+	t.Helper()
+	for _, tt := range tests {
+		paths, definitions := tt.paths, tt.definitions
+		if paths == "" {
+			paths = `{}`
+		}
+		if definitions == "" {
+			definitions = `{}`
+		}
+		schema, rep := convertDoc(t, swaggerDoc(paths, definitions), opts)
+		what := definitions
+		if tt.paths != "" {
+			what = tt.paths
+		}
+		for name, want := range tt.types {
+			if got := typeJSON(schema, name); got != want {
+				t.Errorf("%s: type %s is %s, want %s", what, name, got, want)
+			}
+		}
+		for key, want := range tt.resources {
+			parts := strings.SplitN(key, " ", 2)
+			if got := resourceJSON(schema, parts[0], parts[1]); got != want {
+				t.Errorf("%s: resource %s is %s, want %s", what, key, got, want)
+			}
+		}
+		if tt.warning == "" && len(rep.Warnings) > 0 {
+			t.Errorf("%s: unexpected warnings %s", what, compact(rep.Warnings))
+		}
+		if tt.warning != "" && !hasWarning(rep, tt.warning) {
+			t.Errorf("%s: no warning %q in %s", what, tt.warning, compact(rep.Warnings))
+		}
+	}
+}
+
+// checkErrors converts each set of definitions with the options, checking
+// that it fails with an error containing the text given for it.
+func checkErrors(t *testing.T, opts Options, tests map[string]string) {
+	t.Helper()
+	for definitions, want := range tests {
+		_, err := Convert("test", []byte(swaggerDoc(`{}`, definitions)), opts)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: got error %v, want one containing %q", definitions, err, want)
+		}
+	}
+}
+
+// hasWarning returns true if one of the report's warnings contains the text.
+func hasWarning(rep *Report, text string) bool {
+	for _, w := range rep.Warnings {
+		if strings.Contains(w.Message, text) {
+			return true
+		}
+	}
+	return false
+}
+
+// TestAllOfNonObject checks that an allOf wrapping a single non-object $ref,
+// usually there only to carry a description, imports as the referenced type.
+func TestAllOfNonObject(t *testing.T) {
+	definitions := `{
+		"Status": {"type": "string", "enum": ["on", "off"]},
+		"Names": {"type": "array", "items": {"type": "string"}},
+		"Thing": {"type": "object", "properties": {"status": {"allOf": [{"$ref": "#/definitions/Status"}], "description": "the status"}}},
+		"Current": {"allOf": [{"$ref": "#/definitions/Status"}], "description": "the current status"}
+	}`
+	schema, _ := convertDoc(t, swaggerDoc(`{}`, definitions), Options{})
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Thing", `{"StructTypeDef":{"type":"Struct","name":"Thing","fields":[{"name":"status","type":"Status","optional":true,"comment":"the status"}]}}`},
+		{"Current", `{"AliasTypeDef":{"type":"Status","name":"Current","comment":"the current status"}}`},
+		{"Thing_Status", ``},
+	}
+	for _, tt := range tests {
+		if got := typeJSON(schema, tt.name); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	//a merge with a non-object is still an error
+	for _, allOf := range []string{
+		`[{"$ref": "#/definitions/Names"}, {"type": "object", "properties": {"n": {"type": "integer"}}}]`,
+		`[{"type": "object", "properties": {"n": {"type": "integer"}}}, {"$ref": "#/definitions/Status"}]`,
+	} {
+		doc := swaggerDoc(`{}`, `{
+			"Status": {"type": "string", "enum": ["on", "off"]},
+			"Names": {"type": "array", "items": {"type": "string"}},
+			"Bad": {"allOf": `+allOf+`}
+		}`)
+		_, err := Convert("test", []byte(doc), Options{})
+		if err == nil || !strings.Contains(err.Error(), "which is not an object") {
+			t.Errorf("allOf %s: got error %v, want one about a non-object", allOf, err)
+		}
+	}
+}
+
+// TestNumericKeywords checks that the standard numeric keywords on a property
+// give it a type of its own that keeps them, without any warnings, as does an
+// unknown x-constraint.
+func TestNumericKeywords(t *testing.T) {
+	tests := []struct {
+		property string
+		want     string
+		warning  string
+	}{
+		{
+			`{"type": "number"}`,
+			`Float32`,
+			``,
+		},
+		{
+			`{"type": "number", "minimum": 1, "maximum": 2}`,
+			`{"NumberTypeDef":{"type":"Float64","name":"T_P","min":{"Float64":1},"max":{"Float64":2}}}`,
+			``,
+		},
+		{
+			`{"type": "number", "multipleOf": 0.5}`,
+			`{"NumberTypeDef":{"type":"Float64","name":"T_P","annotations":{"x_multipleOf":"0.5"}}}`,
+			``,
+		},
+		{
+			`{"type": "integer", "minimum": 0, "exclusiveMinimum": true}`,
+			`{"NumberTypeDef":{"type":"Int32","name":"T_P","annotations":{"x_exclusiveMinimum":"true"},"min":{"Int32":0}}}`,
+			``,
+		},
+		{
+			`{"type": "number", "exclusiveMaximum": true}`,
+			`{"NumberTypeDef":{"type":"Float64","name":"T_P","annotations":{"x_exclusiveMaximum":"true"}}}`,
+			``,
+		},
+		{
+			`{"type": "number", "x-constraint": {"positive": true, "odd": true}}`,
+			`{"NumberTypeDef":{"type":"Float64","name":"T_P","annotations":{"x_constraint_odd":"true"},"min":{"Float64":0}}}`,
+			``,
+		},
+	}
+	for _, tt := range tests {
+		schema, rep := convertDoc(t, swaggerDoc(`{}`, `{"T": {"type": "object", "properties": {"p": `+tt.property+`}}}`), Options{})
+		got := typeJSON(schema, "T_P")
+		if got == "" {
+			got = string(schema.Types[0].StructTypeDef.Fields[0].Type)
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.property, got, tt.want)
+		}
+		if tt.warning == "" && len(rep.Warnings) > 0 {
+			t.Errorf("%s: unexpected warnings %s", tt.property, compact(rep.Warnings))
+		}
+		if tt.warning != "" && !hasWarning(rep, tt.warning) {
+			t.Errorf("%s: no warning %q in %s", tt.property, tt.warning, compact(rep.Warnings))
+		}
+	}
+}
+
+// TestErrorLocation checks that errors in nested schemas give the full path to
+// the schema at fault.
+func TestErrorLocation(t *testing.T) {
+	tests := []struct {
+		definitions string
+		want        string
+	}{
+		{
+			`{"User": {"type": "object", "properties": {"address": {"type": "array", "items": {"type": "integer", "x-constraint": {"range": [5, 1]}}}}}}`,
+			`definitions.User.address.items: bad x-constraint range for User_Address_Item`,
+		},
+		{
+			`{"User": {"type": "object", "properties": {"name": {"type": "string", "minLength": 2, "x-constraint": {"length": 3}}}}}`,
+			`definitions.User.name: `,
+		},
+		{
+			`{"User": {"type": "object", "properties": {"tags": {"type": "array", "items": {"type": "string", "x-constraint": {"length": -1}}}}}}`,
+			`definitions.User.tags.items: bad x-constraint length`,
+		},
+	}
+	for _, tt := range tests {
+		_, err := Convert("test", []byte(swaggerDoc(`{}`, tt.definitions)), Options{})
+		if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+			t.Errorf("%s: got error %v, want one starting %q", tt.definitions, err, tt.want)
+		}
+	}
+}
+
+// TestFlattenPaths checks that FlattenPaths names resources, and the types
+// made up for them, after their flattened paths, telling apart the names that
+// collide, and leaves the paths themselves alone.
+func TestFlattenPaths(t *testing.T) {
+	paths := `{
+		"/users": {"get": {"responses": {"200": {"description": "ok", "schema": {"type": "string", "enum": ["a", "b"]}}}}},
+		"/users/{id}": {
+			"get": {"parameters": [{"name": "id", "in": "path", "required": true, "type": "string"}], "responses": {"200": {"description": "ok", "schema": {"type": "string", "enum": ["c", "d"]}}}},
+			"put": {"parameters": [{"name": "id", "in": "path", "required": true, "type": "string"}, {"name": "body", "in": "body", "required": true, "schema": {"type": "object", "properties": {"n": {"type": "string"}}}}], "responses": {"204": {"description": "done"}}}
+		},
+		"/users/{id}/orders/{orderId}": {"get": {"operationId": "getOrder", "parameters": [{"name": "id", "in": "path", "required": true, "type": "string"}, {"name": "orderId", "in": "path", "required": true, "type": "string"}], "responses": {"200": {"description": "ok", "schema": {"type": "string"}}}}},
+		"/teams/{team}/members": {"get": {"parameters": [{"name": "team", "in": "path", "required": true, "type": "string"}], "responses": {"200": {"description": "ok", "schema": {"type": "string"}}}}}
+	}`
+	tests := []struct {
+		flatten bool
+		method  string
+		path    string
+		name    string
+		rtype   string
+	}{
+		{false, "GET", "/users", "getUsers", "GetUsersResponse"},
+		{false, "GET", "/users/{id}", "getUsersById", "GetUsersIdResponse"},
+		{false, "PUT", "/users/{id}", "putUsersById", "PutUsersIdRequest"},
+		{false, "GET", "/teams/{team}/members", "getTeamsByTeamMembers", "String"},
+		{true, "GET", "/users", "getUsers", "GetUsersResponse"},
+		{true, "GET", "/users/{id}", "getUsers2", "GetUsers2Response"},
+		{true, "PUT", "/users/{id}", "putUsers", "PutUsersRequest"},
+		{true, "GET", "/users/{id}/orders/{orderId}", "getOrder", "String"},
+		{true, "GET", "/teams/{team}/members", "getTeamsMembers", "String"},
+	}
+	for _, flatten := range []bool{false, true} {
+		schema, _ := convertDoc(t, swaggerDoc(paths, `{}`), Options{FlattenPaths: flatten})
+		for _, tt := range tests {
+			if tt.flatten != flatten {
+				continue
+			}
+			got := resourceJSON(schema, tt.method, tt.path)
+			if got == "" {
+				t.Errorf("flatten %v: no resource %s %s", flatten, tt.method, tt.path)
+				continue
+			}
+			var r rdl.Resource
+			json.Unmarshal([]byte(got), &r)
+			if string(r.Name) != tt.name || string(r.Type) != tt.rtype {
+				t.Errorf("flatten %v: %s %s is named %s with type %s, want %s with type %s", flatten, tt.method, tt.path, r.Name, r.Type, tt.name, tt.rtype)
+			}
+			if tt.rtype != "String" && typeJSON(schema, tt.rtype) == "" {
+				t.Errorf("flatten %v: no type %s", flatten, tt.rtype)
+			}
+		}
+		if err := rebuild(schema); err != nil {
+			t.Errorf("flatten %v: the schema does not rebuild: %v", flatten, err)
+		}
+	}
+}
+
+// TestPropertyCounts checks that objects with only additionalProperties become
+// maps keeping their property count bounds, which a struct cannot keep.
+func TestPropertyCounts(t *testing.T) {
+	checkImport(t, Options{}, []importCase{
+		{
+			definitions: `{"M": {"type": "object", "additionalProperties": {"type": "string"}, "minProperties": 1, "maxProperties": 5}}`,
+			types: map[string]string{
+				"M": `{"MapTypeDef":{"type":"Map","name":"M","annotations":{"x_maxProperties":"5","x_minProperties":"1"},"keys":"String","items":"String"}}`,
+			},
+		},
+		{
+			definitions: `{"F": {"type": "object", "properties": {"m": {"type": "object", "additionalProperties": {"type": "integer"}, "maxProperties": 3}}}}`,
+			types: map[string]string{
+				"F_M": `{"MapTypeDef":{"type":"Map","name":"F_M","annotations":{"x_maxProperties":"3"},"keys":"String","items":"Int32"}}`,
+				"F":   `{"StructTypeDef":{"type":"Struct","name":"F","fields":[{"name":"m","type":"F_M","optional":true}]}}`,
+			},
+		},
+		{
+			definitions: `{"S": {"type": "object", "properties": {"a": {"type": "string"}}, "minProperties": 1}}`,
+			types: map[string]string{
+				"S": `{"StructTypeDef":{"type":"Struct","name":"S","fields":[{"name":"a","type":"String","optional":true}]}}`,
+			},
+			warning: "minProperties/maxProperties ignored on struct type S",
+		},
+	})
+}
+
+// TestConstraintLength checks that an x-constraint length fixes the size of
+// strings and arrays, and must agree with their other size bounds.
+func TestConstraintLength(t *testing.T) {
+	checkImport(t, Options{}, []importCase{
+		{
+			definitions: `{"Code": {"type": "string", "x-constraint": {"length": 4}}}`,
+			types:       map[string]string{"Code": `{"StringTypeDef":{"type":"String","name":"Code","minSize":4,"maxSize":4}}`},
+		},
+		{
+			definitions: `{"Pair": {"type": "array", "items": {"type": "integer"}, "x-constraint": {"length": 2}}}`,
+			types:       map[string]string{"Pair": `{"ArrayTypeDef":{"type":"Array","name":"Pair","items":"Int32","minSize":2,"maxSize":2}}`},
+		},
+		{
+			definitions: `{"Same": {"type": "string", "minLength": 3, "x-constraint": {"length": 3}}}`,
+			types:       map[string]string{"Same": `{"StringTypeDef":{"type":"String","name":"Same","minSize":3,"maxSize":3}}`},
+		},
+	})
+	checkErrors(t, Options{}, map[string]string{
+		`{"Bad": {"type": "array", "items": {"type": "string"}, "maxItems": 1, "x-constraint": {"length": 2}}}`: "x-constraint length 2 for Bad conflicts with maxItems 1",
+		`{"Bad": {"type": "string", "minLength": 5, "x-constraint": {"length": 2}}}`:                            "x-constraint length 2 for Bad conflicts with minLength 5",
+		`{"Bad": {"type": "string", "x-constraint": {"length": -1}}}`:                                           "bad x-constraint length for Bad",
+	})
+}
+
+// TestTypeArrays checks that a type given as an array of types becomes the
+// type itself, annotated nullable, if the other is null, and a union of the
+// types otherwise, and that a schema without a type takes the one implied by
+// its properties or items.
+func TestTypeArrays(t *testing.T) {
+	checkImport(t, Options{}, []importCase{
+		{
+			definitions: `{"N": {"type": ["string", "null"]}}`,
+			types:       map[string]string{"N": `{"AliasTypeDef":{"type":"String","name":"N","annotations":{"x_nullable":"true"}}}`},
+		},
+		{
+			definitions: `{"U": {"type": ["string", "integer"]}}`,
+			types:       map[string]string{"U": `{"UnionTypeDef":{"type":"Union","name":"U","variants":["String","Int32"]}}`},
+		},
+		{
+			definitions: `{"F": {"type": "object", "properties": {"a": {"type": ["integer", "null"]}, "b": {"type": ["string", "boolean"]}}}}`,
+			types: map[string]string{
+				"F":   `{"StructTypeDef":{"type":"Struct","name":"F","fields":[{"name":"a","type":"Int32","optional":true,"annotations":{"x_nullable":"true"}},{"name":"b","type":"F_B","optional":true}]}}`,
+				"F_B": `{"UnionTypeDef":{"type":"Union","name":"F_B","variants":["String","Bool"]}}`,
+			},
+		},
+		{
+			definitions: `{"P": {"properties": {"a": {"type": "string"}}}, "I": {"items": {"type": "string"}}}`,
+			types: map[string]string{
+				"P": `{"StructTypeDef":{"type":"Struct","name":"P","fields":[{"name":"a","type":"String","optional":true}]}}`,
+				"I": `{"ArrayTypeDef":{"type":"Array","name":"I","items":"String"}}`,
+			},
+		},
+	})
+}
+
+// TestEmptyObject checks each EmptyObject mode on free-form objects, and
+// that an explicitly empty set of properties is always a struct.
+func TestEmptyObject(t *testing.T) {
+	definitions := `{"E": {"type": "object"}, "F": {"type": "object", "properties": {"e": {"type": "object"}, "p": {"type": "object", "properties": {}}}}}`
+	tests := []struct {
+		mode string
+		e    string
+		f    string
+	}{
+		{
+			"",
+			`{"StructTypeDef":{"type":"Struct","name":"E","fields":[]}}`,
+			`{"StructTypeDef":{"type":"Struct","name":"F","fields":[{"name":"e","type":"Struct","optional":true},{"name":"p","type":"F_P","optional":true}]}}`,
+		},
+		{
+			"struct",
+			`{"StructTypeDef":{"type":"Struct","name":"E","fields":[]}}`,
+			`{"StructTypeDef":{"type":"Struct","name":"F","fields":[{"name":"e","type":"Struct","optional":true},{"name":"p","type":"F_P","optional":true}]}}`,
+		},
+		{
+			"any",
+			`{"AliasTypeDef":{"type":"Any","name":"E"}}`,
+			`{"StructTypeDef":{"type":"Struct","name":"F","fields":[{"name":"e","type":"Any","optional":true},{"name":"p","type":"F_P","optional":true}]}}`,
+		},
+		{
+			"map",
+			`{"MapTypeDef":{"type":"Map","name":"E","keys":"String","items":"Any"}}`,
+			`{"StructTypeDef":{"type":"Struct","name":"F","fields":[{"name":"e","type":"Map","optional":true,"items":"Any","keys":"String"},{"name":"p","type":"F_P","optional":true}]}}`,
+		},
+	}
+	for _, tt := range tests {
+		checkImport(t, Options{EmptyObject: tt.mode}, []importCase{{
+			definitions: definitions,
+			types: map[string]string{
+				"E":   tt.e,
+				"F":   tt.f,
+				"F_P": `{"StructTypeDef":{"type":"Struct","name":"F_P","fields":[]}}`,
+			},
+		}})
+	}
+	if _, err := Convert("test", []byte(swaggerDoc(`{}`, definitions)), Options{EmptyObject: "open"}); err == nil || err.Error() != `bad empty object mode: "open"` {
+		t.Errorf("got error %v for a bad mode", err)
+	}
+}
+
+// TestURIFormats checks that the uri formats are told apart in x_format.
+func TestURIFormats(t *testing.T) {
+	var tests []importCase
+	for _, format := range []string{"uri", "uri-reference", "uri-template"} {
+		tests = append(tests,
+			importCase{
+				definitions: `{"U": {"type": "string", "format": "` + format + `"}}`,
+				types:       map[string]string{"U": `{"AliasTypeDef":{"type":"String","name":"U","annotations":{"x_format":"` + format + `"}}}`},
+			},
+			importCase{
+				definitions: `{"F": {"type": "object", "properties": {"u": {"type": "string", "format": "` + format + `"}}}}`,
+				types:       map[string]string{"F": `{"StructTypeDef":{"type":"Struct","name":"F","fields":[{"name":"u","type":"String","optional":true,"annotations":{"x_format":"` + format + `"}}]}}`},
+			})
+	}
+	checkImport(t, Options{}, tests)
+}
+
+// TestKeyType checks the map key types x-key-type can give, and those it
+// cannot.
+func TestKeyType(t *testing.T) {
+	checkImport(t, Options{}, []importCase{
+		{
+			definitions: `{"C": {"type": "object", "additionalProperties": {"type": "integer"}, "x-key-type": "int32"}}`,
+			types:       map[string]string{"C": `{"MapTypeDef":{"type":"Map","name":"C","keys":"Int32","items":"Int32"}}`},
+		},
+		{
+			definitions: `{"C": {"type": "object", "additionalProperties": {"type": "integer"}, "x-key-type": "UUID"}}`,
+			types:       map[string]string{"C": `{"MapTypeDef":{"type":"Map","name":"C","keys":"UUID","items":"Int32"}}`},
+		},
+		{
+			definitions: `{"D": {"type": "object", "additionalProperties": {"type": "string"}, "x-key-type": "Id"}, "Id": {"type": "string"}}`,
+			types:       map[string]string{"D": `{"MapTypeDef":{"type":"Map","name":"D","keys":"Id","items":"String"}}`},
+		},
+		{
+			definitions: `{"F": {"type": "object", "properties": {"m": {"type": "object", "additionalProperties": {"type": "string"}, "x-key-type": "int64"}}}}`,
+			types: map[string]string{
+				"F":   `{"StructTypeDef":{"type":"Struct","name":"F","fields":[{"name":"m","type":"F_M","optional":true}]}}`,
+				"F_M": `{"MapTypeDef":{"type":"Map","name":"F_M","keys":"Int64","items":"String"}}`,
+			},
+		},
+	})
+	checkErrors(t, Options{}, map[string]string{
+		`{"C": {"type": "object", "additionalProperties": {"type": "integer"}, "x-key-type": "boolean"}}`: "bad x-key-type for C: boolean is not a valid map key type",
+		`{"C": {"type": "object", "additionalProperties": {"type": "integer"}, "x-key-type": 3}}`:         "bad x-key-type for C: 3",
+		`{"C": {"type": "object", "additionalProperties": {"type": "integer"}, "x-key-type": "Nope"}}`:    "bad x-key-type for C: Nope is not defined",
+	})
+}
+
+// TestEnumItems checks that arrays of enum-constrained items get an enum type
+// for their items, shared by the arrays with the same values.
+func TestEnumItems(t *testing.T) {
+	checkImport(t, Options{}, []importCase{
+		{
+			definitions: `{"Colors": {"type": "array", "items": {"type": "string", "enum": ["red", "green"]}}}`,
+			types: map[string]string{
+				"Colors":      `{"ArrayTypeDef":{"type":"Array","name":"Colors","items":"Colors_Item"}}`,
+				"Colors_Item": `{"EnumTypeDef":{"type":"Enum","name":"Colors_Item","elements":[{"symbol":"red"},{"symbol":"green"}]}}`,
+			},
+		},
+		{
+			definitions: `{"F": {"type": "object", "properties": {"tags": {"type": "array", "items": {"type": "string", "enum": ["a", "b"]}}}}}`,
+			types: map[string]string{
+				"F":           `{"StructTypeDef":{"type":"Struct","name":"F","fields":[{"name":"tags","type":"F_Tags","optional":true}]}}`,
+				"F_Tags":      `{"ArrayTypeDef":{"type":"Array","name":"F_Tags","items":"F_Tags_Item"}}`,
+				"F_Tags_Item": `{"EnumTypeDef":{"type":"Enum","name":"F_Tags_Item","elements":[{"symbol":"a"},{"symbol":"b"}]}}`,
+			},
+		},
+		{
+			definitions: `{"A": {"type": "array", "items": {"type": "string", "enum": ["x", "y"]}}, "B": {"type": "array", "items": {"type": "string", "enum": ["x", "y"]}}}`,
+			types: map[string]string{
+				"A":      `{"ArrayTypeDef":{"type":"Array","name":"A","items":"A_Item"}}`,
+				"B":      `{"ArrayTypeDef":{"type":"Array","name":"B","items":"A_Item"}}`,
+				"B_Item": ``,
+			},
+		},
+	})
+}
+
+// TestEnumResponses checks that inline enum responses get an enum type named
+// after the operation, shared by the responses with the same values.
+func TestEnumResponses(t *testing.T) {
+	checkImport(t, Options{}, []importCase{
+		{
+			paths: `{
+				"/s": {"get": {"operationId": "getStatus", "responses": {"200": {"description": "ok", "schema": {"type": "string", "enum": ["up", "down"]}}}}},
+				"/t": {"get": {"responses": {"200": {"description": "ok", "schema": {"type": "string", "enum": ["up", "down"]}}}}},
+				"/u/{id}": {"get": {"parameters": [{"name": "id", "in": "path", "required": true, "type": "string"}], "responses": {"200": {"description": "ok", "schema": {"type": "string", "enum": ["a", "b"]}}}}}
+			}`,
+			types: map[string]string{
+				"GetStatusResponse": `{"EnumTypeDef":{"type":"Enum","name":"GetStatusResponse","elements":[{"symbol":"up"},{"symbol":"down"}]}}`,
+				"GetTResponse":      ``,
+				"GetUIdResponse":    `{"EnumTypeDef":{"type":"Enum","name":"GetUIdResponse","elements":[{"symbol":"a"},{"symbol":"b"}]}}`,
+			},
+			resources: map[string]string{
+				"GET /s":      `{"type":"GetStatusResponse","method":"GET","path":"/s","expected":"OK","name":"getStatus"}`,
+				"GET /t":      `{"type":"GetStatusResponse","method":"GET","path":"/t","expected":"OK","name":"getT"}`,
+				"GET /u/{id}": `{"type":"GetUIdResponse","method":"GET","path":"/u/{id}","inputs":[{"name":"id","type":"String","pathParam":true}],"expected":"OK","name":"getUById"}`,
+			},
+		},
+	})
+}
+
+// TestNoExamples checks that NoExamples leaves out the x_example
+// annotations, on types and fields alike.
+func TestNoExamples(t *testing.T) {
+	definitions := `{"T": {"type": "string", "example": "abc"}, "F": {"type": "object", "properties": {"n": {"type": "integer", "example": 3}}, "example": {"n": 3}}}`
+	tests := []struct {
+		noExamples bool
+		t          string
+		f          string
+	}{
+		{
+			false,
+			`{"AliasTypeDef":{"type":"String","name":"T","annotations":{"x_example":"abc"}}}`,
+			`{"StructTypeDef":{"type":"Struct","name":"F","annotations":{"x_example":"{\"n\":3}"},"fields":[{"name":"n","type":"Int32","optional":true,"annotations":{"x_example":"3"}}]}}`,
+		},
+		{
+			true,
+			`{"AliasTypeDef":{"type":"String","name":"T"}}`,
+			`{"StructTypeDef":{"type":"Struct","name":"F","fields":[{"name":"n","type":"Int32","optional":true}]}}`,
+		},
+	}
+	for _, tt := range tests {
+		checkImport(t, Options{NoExamples: tt.noExamples}, []importCase{{
+			definitions: definitions,
+			types:       map[string]string{"T": tt.t, "F": tt.f},
+		}})
+	}
+}
+
+// TestChar checks that single-character strings are annotated x_format_char,
+// while a maxLength of 1 alone, which allows the empty string, is not.
+func TestChar(t *testing.T) {
+	checkImport(t, Options{}, []importCase{
+		{
+			definitions: `{"C": {"type": "string", "format": "char"}}`,
+			types:       map[string]string{"C": `{"StringTypeDef":{"type":"String","name":"C","annotations":{"x_format_char":"true"},"minSize":1,"maxSize":1}}`},
+		},
+		{
+			definitions: `{"F": {"type": "object", "properties": {"c": {"type": "string", "format": "char"}}}}`,
+			types:       map[string]string{"F": `{"StructTypeDef":{"type":"Struct","name":"F","fields":[{"name":"c","type":"String","optional":true,"annotations":{"x_format_char":"true"}}]}}`},
+		},
+		{
+			definitions: `{"F": {"type": "object", "properties": {"a": {"type": "string", "maxLength": 1}, "b": {"type": "string", "minLength": 1, "maxLength": 1}}}}`,
+			types: map[string]string{
+				"F_A": `{"StringTypeDef":{"type":"String","name":"F_A","maxSize":1}}`,
+				"F_B": `{"StringTypeDef":{"type":"String","name":"F_B","annotations":{"x_format_char":"true"},"minSize":1,"maxSize":1}}`,
+			},
+		},
+	})
+}
+
+// TestRefTypeName checks the decoding of the definition names in local $refs.
+func TestRefTypeName(t *testing.T) {
+	tests := []struct {
+		ref  string
+		name string
+		ok   bool
+	}{
+		{"#/definitions/Pet", "Pet", true},
+		{"#/definitions/Foo~1Bar", "Foo/Bar", true},
+		{"#/definitions/a~0b", "a~b", true},
+		{"#/definitions/~01", "~1", true},
+		{"#/definitions/My%20Type", "My Type", true},
+		{"#/definitions/100%", "100%", true},
+		{"#/parameters/Pet", "", false},
+		{"other.json#/definitions/Pet", "", false},
+	}
+	for _, tt := range tests {
+		name, ok := refTypeName(tt.ref)
+		if name != tt.name || ok != tt.ok {
+			t.Errorf("%s: got %q, %v, want %q, %v", tt.ref, name, ok, tt.name, tt.ok)
+		}
+	}
+	checkImport(t, Options{}, []importCase{{
+		definitions: `{"Foo/Bar": {"type": "string"}, "My Type": {"type": "integer"}, "U": {"type": "object", "properties": {"a": {"$ref": "#/definitions/Foo~1Bar"}, "b": {"$ref": "#/definitions/My%20Type"}}}}`,
+		types: map[string]string{
+			"FooBar": `{"AliasTypeDef":{"type":"String","name":"FooBar"}}`,
+			"MyType": `{"NumberTypeDef":{"type":"Int32","name":"MyType"}}`,
+			"U":      `{"StructTypeDef":{"type":"Struct","name":"U","fields":[{"name":"a","type":"FooBar","optional":true},{"name":"b","type":"MyType","optional":true}]}}`,
+		},
+	}})
+}
+
+// TestExternalDocs checks that externalDocs become x_externalDocs annotations
+// on the schema, resources, types and fields, with or without a description.
+func TestExternalDocs(t *testing.T) {
+	doc := `{"swagger": "2.0", "info": {"title": "t", "version": "1"}, "externalDocs": {"url": "https://example.com", "description": "all of it"},
+		"paths": {"/x": {"get": {"operationId": "getX", "externalDocs": {"url": "https://example.com/x"}, "responses": {"200": {"description": "ok", "schema": {"type": "string"}}}}}}}`
+	schema, _ := convertDoc(t, doc, Options{})
+	if got := compact(schema.Annotations); got != `{"x_externalDocs":"https://example.com","x_externalDocs_description":"all of it"}` {
+		t.Errorf("schema annotations are %s", got)
+	}
+	if got := resourceJSON(schema, "GET", "/x"); got != `{"type":"String","method":"GET","path":"/x","expected":"OK","annotations":{"x_externalDocs":"https://example.com/x"},"name":"getX"}` {
+		t.Errorf("resource is %s", got)
+	}
+	checkImport(t, Options{}, []importCase{
+		{
+			definitions: `{"T": {"type": "string", "externalDocs": {"url": "https://example.com/t", "description": "more"}}}`,
+			types:       map[string]string{"T": `{"AliasTypeDef":{"type":"String","name":"T","annotations":{"x_externalDocs":"https://example.com/t","x_externalDocs_description":"more"}}}`},
+		},
+		{
+			definitions: `{"T": {"type": "string"}, "F": {"type": "object", "properties": {
+				"a": {"type": "string", "externalDocs": {"url": "https://example.com/a"}},
+				"b": {"$ref": "#/definitions/T", "externalDocs": {"url": "https://example.com/b", "description": "bee"}},
+				"c": {"type": "string", "pattern": "^x", "externalDocs": {"url": "https://example.com/c"}}
+			}}}`,
+			types: map[string]string{
+				"F":   `{"StructTypeDef":{"type":"Struct","name":"F","fields":[{"name":"a","type":"String","optional":true,"annotations":{"x_externalDocs":"https://example.com/a"}},{"name":"b","type":"T","optional":true,"annotations":{"x_externalDocs":"https://example.com/b","x_externalDocs_description":"bee"}},{"name":"c","type":"F_C","optional":true}]}}`,
+				"F_C": `{"StringTypeDef":{"type":"String","name":"F_C","annotations":{"x_externalDocs":"https://example.com/c"},"pattern":"^x"}}`,
+			},
+		},
+	})
+}
+
+// TestNumericConstraints checks the x-constraint keys that bound integers and
+// numbers, and that any other is kept as an annotation on either.
+func TestNumericConstraints(t *testing.T) {
+	tests := []struct {
+		typ        string
+		constraint string
+		want       string
+	}{
+		{"integer", `{"positive": true}`, `{"NumberTypeDef":{"type":"Int32","name":"I","min":{"Int32":0}}}`},
+		{"integer", `{"negative": true}`, `{"NumberTypeDef":{"type":"Int32","name":"I","max":{"Int32":-1}}}`},
+		{"integer", `{"nonnegative": true}`, `{"NumberTypeDef":{"type":"Int32","name":"I","min":{"Int32":0}}}`},
+		{"integer", `{"range": [1, 10]}`, `{"NumberTypeDef":{"type":"Int32","name":"I","min":{"Int32":1},"max":{"Int32":10}}}`},
+		{"integer", `{"odd": true}`, `{"NumberTypeDef":{"type":"Int32","name":"I","annotations":{"x_constraint_odd":"true"}}}`},
+		{"number", `{"positive": true}`, `{"NumberTypeDef":{"type":"Float64","name":"I","min":{"Float64":0}}}`},
+		{"number", `{"negative": true}`, `{"NumberTypeDef":{"type":"Float64","name":"I","max":{"Float64":0}}}`},
+		{"number", `{"nonnegative": true}`, `{"NumberTypeDef":{"type":"Float64","name":"I","min":{"Float64":0}}}`},
+		{"number", `{"range": [0.5, 10]}`, `{"NumberTypeDef":{"type":"Float64","name":"I","min":{"Float64":0.5},"max":{"Float64":10}}}`},
+		{"number", `{"odd": true}`, `{"NumberTypeDef":{"type":"Float64","name":"I","annotations":{"x_constraint_odd":"true"}}}`},
+	}
+	var cases []importCase
+	for _, tt := range tests {
+		cases = append(cases, importCase{
+			definitions: `{"I": {"type": "` + tt.typ + `", "x-constraint": ` + tt.constraint + `}}`,
+			types:       map[string]string{"I": tt.want},
+		})
+	}
+	cases = append(cases, importCase{
+		definitions: `{"I": {"type": "integer", "format": "int64", "x-constraint": {"negative": true}}}`,
+		types:       map[string]string{"I": `{"NumberTypeDef":{"type":"Int64","name":"I","max":{"Int64":-1}}}`},
+	})
+	checkImport(t, Options{}, cases)
+	checkErrors(t, Options{}, map[string]string{
+		`{"I": {"type": "integer", "x-constraint": {"range": [10, 1]}}}`:  "bad x-constraint range for I",
+		`{"I": {"type": "integer", "x-constraint": {"range": [1.5, 2]}}}`: "bad x-constraint range for I",
+		`{"I": {"type": "integer", "x-constraint": {"range": 3}}}`:        "bad x-constraint range for I",
+		`{"I": {"type": "number", "x-constraint": {"range": [2, 0.5]}}}`:  "bad x-constraint range for I",
+	})
+}
+
+// TestResourceNames checks the names made up for operations without an
+// operationId, which must not collide with each other or with the
+// operationIds.
+func TestResourceNames(t *testing.T) {
+	paths := `{
+		"/users": {"get": {"responses": {"200": {"description": "ok", "schema": {"type": "string"}}}}},
+		"/users/": {"get": {"responses": {"200": {"description": "ok", "schema": {"type": "string"}}}}},
+		"/users/{id}": {"get": {"parameters": [{"name": "id", "in": "path", "required": true, "type": "string"}], "responses": {"200": {"description": "ok", "schema": {"type": "string"}}}}},
+		"/other": {"get": {"operationId": "getUsers2", "responses": {"200": {"description": "ok", "schema": {"type": "string"}}}}},
+		"/a-b/c_d": {"post": {"responses": {"200": {"description": "ok", "schema": {"type": "string"}}}}}
+	}`
+	tests := []struct {
+		method string
+		path   string
+		name   string
+	}{
+		{"GET", "/users", "getUsers"},
+		{"GET", "/users/", "getUsers3"},
+		{"GET", "/users/{id}", "getUsersById"},
+		{"GET", "/other", "getUsers2"},
+		{"POST", "/a-b/c_d", "postABC_d"},
+	}
+	schema, _ := convertDoc(t, swaggerDoc(paths, `{}`), Options{})
+	for _, tt := range tests {
+		var r rdl.Resource
+		json.Unmarshal([]byte(resourceJSON(schema, tt.method, tt.path)), &r)
+		if string(r.Name) != tt.name {
+			t.Errorf("%s %s is named %q, want %q", tt.method, tt.path, r.Name, tt.name)
+		}
+	}
+}
+
+// TestConsumesProduces checks that an operation's consumes and produces
+// override the document's, which apply otherwise.
+func TestConsumesProduces(t *testing.T) {
+	doc := `{"swagger": "2.0", "info": {"title": "t", "version": "1"}, "consumes": ["application/json"], "produces": ["application/json", "application/xml"], "paths": {
+		"/a": {"post": {"parameters": [{"name": "b", "in": "body", "schema": {"type": "string"}}], "responses": {"200": {"description": "ok", "schema": {"type": "string"}}}}},
+		"/b": {"post": {"consumes": ["text/plain"], "produces": ["text/csv"], "parameters": [{"name": "b", "in": "body", "schema": {"type": "string"}}], "responses": {"200": {"description": "ok", "schema": {"type": "string"}}}}},
+		"/c": {"get": {"produces": [], "responses": {"200": {"description": "ok", "schema": {"type": "string"}}}}}
+	}}`
+	tests := []struct {
+		method   string
+		path     string
+		consumes []string
+		produces []string
+	}{
+		{"POST", "/a", []string{"application/json"}, []string{"application/json", "application/xml"}},
+		{"POST", "/b", []string{"text/plain"}, []string{"text/csv"}},
+		{"GET", "/c", []string{"application/json"}, nil},
+	}
+	schema, rep := convertDoc(t, doc, Options{})
+	for _, tt := range tests {
+		var r rdl.Resource
+		json.Unmarshal([]byte(resourceJSON(schema, tt.method, tt.path)), &r)
+		if compact(r.Consumes) != compact(tt.consumes) || compact(r.Produces) != compact(tt.produces) {
+			t.Errorf("%s %s consumes %v and produces %v, want %v and %v", tt.method, tt.path, r.Consumes, r.Produces, tt.consumes, tt.produces)
+		}
+	}
+	if !hasWarning(rep, "expected to produce something other than application/json: text/csv") {
+		t.Errorf("no warning about text/csv in %s", compact(rep.Warnings))
+	}
+}
+
+// TestUUIDItems checks that format uuid carries through to the items of an
+// array and the values of a map, whether a field or a type of its own.
+func TestUUIDItems(t *testing.T) {
+	checkImport(t, Options{}, []importCase{
+		{
+			definitions: `{"T": {"type": "object", "properties": {"ids": {"type": "array", "items": {"type": "string", "format": "uuid"}}, "m": {"type": "object", "additionalProperties": {"type": "string", "format": "uuid"}}}}}`,
+			types: map[string]string{
+				"T": `{"StructTypeDef":{"type":"Struct","name":"T","fields":[{"name":"ids","type":"Array","optional":true,"items":"UUID"},{"name":"m","type":"Map","optional":true,"items":"UUID","keys":"String"}]}}`,
+			},
+		},
+		{
+			definitions: `{"Ids": {"type": "array", "items": {"type": "string", "format": "uuid"}}, "M": {"type": "object", "additionalProperties": {"type": "string", "format": "uuid"}}}`,
+			types: map[string]string{
+				"Ids": `{"ArrayTypeDef":{"type":"Array","name":"Ids","items":"UUID"}}`,
+				"M":   `{"MapTypeDef":{"type":"Map","name":"M","keys":"String","items":"UUID"}}`,
+			},
+		},
+	})
+}
+
+// TestDefaultInt checks that DefaultInt gives the type of integers with no
+// format, wherever they are, and that an explicit format still wins.
+func TestDefaultInt(t *testing.T) {
+	definitions := `{"T": {"type": "object", "properties": {"a": {"type": "integer"}, "b": {"type": "integer", "format": "int32"}, "c": {"type": "array", "items": {"type": "integer"}}}}, "N": {"type": "integer"}}`
+	paths := `{"/x": {"get": {"parameters": [{"name": "n", "in": "query", "type": "integer"}], "responses": {"204": {"description": "ok"}}}}}`
+	tests := []struct {
+		defaultInt string
+		want       string
+	}{
+		{"", "Int32"},
+		{"int32", "Int32"},
+		{"int64", "Int64"},
+	}
+	for _, tt := range tests {
+		checkImport(t, Options{DefaultInt: tt.defaultInt}, []importCase{
+			{
+				definitions: definitions,
+				paths:       paths,
+				types: map[string]string{
+					"N": `{"NumberTypeDef":{"type":"` + tt.want + `","name":"N"}}`,
+					"T": `{"StructTypeDef":{"type":"Struct","name":"T","fields":[{"name":"a","type":"` + tt.want + `","optional":true},{"name":"b","type":"Int32","optional":true},{"name":"c","type":"Array","optional":true,"items":"` + tt.want + `"}]}}`,
+				},
+				resources: map[string]string{
+					"GET /x": `{"type":"Any","method":"GET","path":"/x","inputs":[{"name":"n","type":"` + tt.want + `","queryParam":"n"}],"expected":"NO_CONTENT","name":"getX"}`,
+				},
+			},
+		})
+	}
+	if _, err := Convert("test", []byte(swaggerDoc(`{}`, `{}`)), Options{DefaultInt: "int16"}); err == nil {
+		t.Errorf("no error for DefaultInt int16")
+	}
+}
+
+// TestCollectionFormat checks that the collectionFormat of an array parameter
+// is kept as an annotation, unless it is the default csv, and that multi
+// outside the query is warned about.
+func TestCollectionFormat(t *testing.T) {
+	param := func(in, format string) string {
+		p := `{"name": "p", "in": "` + in + `", "type": "array", "items": {"type": "string"}`
+		if format != "" {
+			p += `, "collectionFormat": "` + format + `"`
+		}
+		return `{"/x": {"get": {"parameters": [` + p + `}], "responses": {"204": {"description": "ok"}}}}}`
+	}
+	input := func(where, format string) string {
+		in := `{"name":"p","type":"Array",` + where
+		if format != "" && format != "csv" {
+			in += `,"annotations":{"x_collectionFormat":"` + format + `"}`
+		}
+		return `{"type":"Any","method":"GET","path":"/x","inputs":[` + in + `}],"expected":"NO_CONTENT","name":"getX"}`
+	}
+	var cases []importCase
+	for _, format := range []string{"", "csv", "ssv", "tsv", "pipes", "multi"} {
+		cases = append(cases, importCase{
+			definitions: `{}`,
+			paths:       param("query", format),
+			resources:   map[string]string{"GET /x": input(`"queryParam":"p"`, format)},
+		})
+	}
+	cases = append(cases, importCase{
+		definitions: `{}`,
+		paths:       param("header", "multi"),
+		resources:   map[string]string{"GET /x": input(`"header":"p"`, "multi")},
+		warning:     "collectionFormat multi is only valid for query and formData parameters, not header parameter p",
+	})
+	checkImport(t, Options{}, cases)
+}
+
+// TestValidateExamples checks that ValidateExamples warns about examples
+// that are valid JSON but not valid instances of their types, and not about
+// the others.
+func TestValidateExamples(t *testing.T) {
+	tests := []struct {
+		definitions string
+		warning     string
+	}{
+		{`{"T": {"type": "object", "required": ["n"], "properties": {"n": {"type": "integer"}}, "example": {"n": 3}}}`, ""},
+		{`{"T": {"type": "object", "properties": {"n": {"type": "integer", "example": 3}}}}`, ""},
+		{`{"T": {"type": "object", "required": ["n"], "properties": {"n": {"type": "integer"}}, "example": {"n": "x"}}}`, "example does not match T: Bad Int32"},
+		{`{"T": {"type": "object", "required": ["n"], "properties": {"n": {"type": "integer"}}, "example": {"s": "a"}}}`, "example does not match T: Field missing: n"},
+		{`{"T": {"type": "string", "example": 5}}`, "example does not match T: Not a string"},
+	}
+	for _, tt := range tests {
+		checkImport(t, Options{ValidateExamples: true}, []importCase{{definitions: tt.definitions, warning: tt.warning}})
+		checkImport(t, Options{}, []importCase{{definitions: tt.definitions}})
+	}
+}
+
+// TestRefToConstrainedString checks that a $ref to a constrained string is
+// the named type, not String, even when a type is given beside the $ref.
+func TestRefToConstrainedString(t *testing.T) {
+	email := `"Email": {"type": "string", "pattern": "^.+@.+$"}`
+	checkImport(t, Options{}, []importCase{
+		{
+			definitions: `{` + email + `, "T": {"type": "object", "properties": {"e": {"$ref": "#/definitions/Email"}, "f": {"type": "string", "$ref": "#/definitions/Email"}, "g": {"type": "array", "items": {"type": "string", "$ref": "#/definitions/Email"}}}}}`,
+			types: map[string]string{
+				"Email": `{"StringTypeDef":{"type":"String","name":"Email","pattern":"^.+@.+$"}}`,
+				"T":     `{"StructTypeDef":{"type":"Struct","name":"T","fields":[{"name":"e","type":"Email","optional":true},{"name":"f","type":"Email","optional":true},{"name":"g","type":"Array","optional":true,"items":"Email"}]}}`,
+			},
+		},
+		{
+			definitions: `{` + email + `}`,
+			paths:       `{"/x": {"post": {"parameters": [{"name": "b", "in": "body", "schema": {"type": "string", "$ref": "#/definitions/Email"}}], "responses": {"200": {"description": "ok", "schema": {"type": "string", "$ref": "#/definitions/Email"}}}}}}`,
+			resources: map[string]string{
+				"POST /x": `{"type":"Email","method":"POST","path":"/x","inputs":[{"name":"b","type":"Email"}],"expected":"OK","name":"postX"}`,
+			},
+		},
+	})
+}
+
+// TestMaxDepth checks that schemas nested just to -max-depth import, and that
+// one level deeper is a clean error.
+func TestMaxDepth(t *testing.T) {
+	nested := func(depth int) string {
+		s := `{"type": "string"}`
+		for i := 0; i < depth; i++ {
+			s = `{"type": "object", "properties": {"a": ` + s + `}}`
+		}
+		return `{"T": ` + s + `}`
+	}
+	tests := []struct {
+		maxDepth int
+		depth    int
+	}{
+		{5, 5},
+		{0, DefaultMaxDepth},
+	}
+	for _, tt := range tests {
+		if _, err := Convert("test", []byte(swaggerDoc(`{}`, nested(tt.depth))), Options{MaxDepth: tt.maxDepth}); err != nil {
+			t.Errorf("max depth %d: nesting %d deep: %v", tt.maxDepth, tt.depth, err)
+		}
+		checkErrors(t, Options{MaxDepth: tt.maxDepth}, map[string]string{
+			nested(tt.depth + 1): fmt.Sprintf("is nested more than %d deep", tt.depth),
+		})
+	}
+}
+
+// TestTitleNames checks that an inline object is named for its title, unless
+// a type of that name already exists, when it falls back to Parent_Field.
+func TestTitleNames(t *testing.T) {
+	address := func(field string) string {
+		return `"` + field + `": {"type": "object", "title": "home address", "properties": {"` + field + `": {"type": "string"}}}`
+	}
+	checkImport(t, Options{}, []importCase{
+		{
+			definitions: `{"T": {"type": "object", "properties": {` + address("a") + `, "c": {"type": "object", "properties": {"x": {"type": "string"}}}}}}`,
+			types: map[string]string{
+				"HomeAddress": `{"StructTypeDef":{"type":"Struct","name":"HomeAddress","fields":[{"name":"a","type":"String","optional":true}]}}`,
+				"T_A":         ``,
+				"T_C":         `{"StructTypeDef":{"type":"Struct","name":"T_C","fields":[{"name":"x","type":"String","optional":true}]}}`,
+				"T":           `{"StructTypeDef":{"type":"Struct","name":"T","fields":[{"name":"a","type":"HomeAddress","optional":true},{"name":"c","type":"T_C","optional":true}]}}`,
+			},
+		},
+		{
+			//two inline objects with the same title
+			definitions: `{"T": {"type": "object", "properties": {` + address("a") + `, ` + address("b") + `}}}`,
+			types: map[string]string{
+				"HomeAddress": `{"StructTypeDef":{"type":"Struct","name":"HomeAddress","fields":[{"name":"a","type":"String","optional":true}]}}`,
+				"T_B":         `{"StructTypeDef":{"type":"Struct","name":"T_B","fields":[{"name":"b","type":"String","optional":true}]}}`,
+			},
+			warning: `title "home address" is already the name of a type, using T_B`,
+		},
+		{
+			//a title that is the name of a definition
+			definitions: `{"HomeAddress": {"type": "string"}, "T": {"type": "object", "properties": {` + address("a") + `}}}`,
+			types: map[string]string{
+				"HomeAddress": `{"AliasTypeDef":{"type":"String","name":"HomeAddress"}}`,
+				"T_A":         `{"StructTypeDef":{"type":"Struct","name":"T_A","fields":[{"name":"a","type":"String","optional":true}]}}`,
+			},
+			warning: `title "home address" is already the name of a type, using T_A`,
+		},
+	})
+}
+
+// TestSingleMethodPaths checks that a path with only one method, other than
+// GET, imports as exactly one resource with that method, taking the path's
+// parameters.
+func TestSingleMethodPaths(t *testing.T) {
+	tests := []struct {
+		method   string
+		expected string
+		rtype    string
+	}{
+		{"patch", "OK", "String"},
+		{"head", "NO_CONTENT", "Any"},
+		{"options", "NO_CONTENT", "Any"},
+		{"delete", "NO_CONTENT", "Any"},
+		{"put", "OK", "String"},
+	}
+	for _, tt := range tests {
+		response := `{"204": {"description": "ok"}}`
+		if tt.expected == "OK" {
+			response = `{"200": {"description": "ok", "schema": {"type": "string"}}}`
+		}
+		paths := `{"/x": {"parameters": [{"name": "q", "in": "query", "type": "string"}], "` + tt.method + `": {"responses": ` + response + `}}}`
+		schema, _ := convertDoc(t, swaggerDoc(paths, `{}`), Options{})
+		method := strings.ToUpper(tt.method)
+		want := `{"type":"` + tt.rtype + `","method":"` + method + `","path":"/x","inputs":[{"name":"q","type":"String","queryParam":"q"}],"expected":"` + tt.expected + `","name":"` + tt.method + `X"}`
+		if len(schema.Resources) != 1 {
+			t.Errorf("%s: %d resources, want 1", method, len(schema.Resources))
+		}
+		if got := resourceJSON(schema, method, "/x"); got != want {
+			t.Errorf("%s: resource is %s, want %s", method, got, want)
+		}
+	}
+}
+
+// TestDateFormat checks that format date imports as a Timestamp annotated
+// x_format_date, with a date-only default read as midnight, and that
+// date-time is a plain Timestamp.
+func TestDateFormat(t *testing.T) {
+	checkImport(t, Options{}, []importCase{
+		{
+			definitions: `{"T": {"type": "object", "properties": {"d": {"type": "string", "format": "date", "default": "2020-01-01"}, "e": {"type": "string", "format": "date-time"}}}}`,
+			types: map[string]string{
+				"T": `{"StructTypeDef":{"type":"Struct","name":"T","fields":[{"name":"d","type":"Timestamp","optional":true,"default":"2020-01-01T00:00:00.000Z","annotations":{"x_format_date":"true"}},{"name":"e","type":"Timestamp","optional":true}]}}`,
+			},
+		},
+		{
+			definitions: `{"D": {"type": "string", "format": "date"}, "E": {"type": "string", "format": "date-time"}}`,
+			types: map[string]string{
+				"D": `{"AliasTypeDef":{"type":"Timestamp","name":"D","annotations":{"x_format_date":"true"}}}`,
+				"E": `{"AliasTypeDef":{"type":"Timestamp","name":"E"}}`,
+			},
+		},
+	})
+}
+
+// TestTitleName checks that a title of the form "The X API" names the schema
+// X, except with KeepName, and that other titles leave the name alone.
+func TestTitleName(t *testing.T) {
+	tests := []struct {
+		title    string
+		keepName bool
+		want     string
+	}{
+		{"The Store API", false, "Store"},
+		{"The Store API", true, "test"},
+		{"The API", false, "test"},
+		{"Pet Store", false, "test"},
+		{"The Pets", false, "test"},
+	}
+	for _, tt := range tests {
+		doc := `{"swagger": "2.0", "info": {"title": "` + tt.title + `", "version": "1"}, "paths": {}}`
+		schema, _ := convertDoc(t, doc, Options{KeepName: tt.keepName})
+		if string(schema.Name) != tt.want {
+			t.Errorf("title %q, keep name %v: name is %q, want %q", tt.title, tt.keepName, schema.Name, tt.want)
+		}
+	}
+}
+
+// TestWildcardResponses checks that a response keyed by a range like 4XX is
+// imported under the range's first code, with the ranges noted in an
+// annotation, and that a range overlapping a concrete code is dropped.
+func TestWildcardResponses(t *testing.T) {
+	checkImport(t, Options{}, []importCase{
+		{
+			paths: `{"/x": {"get": {"responses": {"200": {"description": "ok", "schema": {"type": "string"}}, "4XX": {"description": "client", "schema": {"type": "integer"}}}}}}`,
+			resources: map[string]string{
+				"GET /x": `{"type":"String","method":"GET","path":"/x","expected":"OK","exceptions":{"400":{"type":"Int32"}},"annotations":{"x_response_ranges":"4XX"},"name":"getX"}`,
+			},
+		},
+		{
+			paths: `{"/x": {"get": {"responses": {"2XX": {"description": "ok", "schema": {"type": "string"}}}}}}`,
+			resources: map[string]string{
+				"GET /x": `{"type":"String","method":"GET","path":"/x","expected":"OK","annotations":{"x_response_ranges":"2XX"},"name":"getX"}`,
+			},
+		},
+		{
+			paths: `{"/x": {"get": {"responses": {"200": {"description": "ok", "schema": {"type": "string"}}, "2XX": {"description": "other", "schema": {"type": "integer"}}}}}}`,
+			resources: map[string]string{
+				"GET /x": `{"type":"String","method":"GET","path":"/x","expected":"OK","name":"getX"}`,
+			},
+			warning: "ignoring response for 2XX, which overlaps the response for 200",
+		},
+	})
+}
+
+// TestFeatures checks the report of the constructs dropped or approximated in
+// an import, with where they were and why.
+func TestFeatures(t *testing.T) {
+	definitions := `{"T": {"type": "object", "minProperties": 1, "properties": {"a": {"type": "array"}}}}`
+	paths := `{"/x": {"get": {"produces": ["text/csv"], "responses": {"200": {"description": "ok", "schema": {"type": "string"}}, "2XX": {"description": "x"}}}}}`
+	_, rep := convertDoc(t, swaggerDoc(paths, definitions), Options{})
+	want := []Feature{
+		{"minProperties", "definitions.T", "minProperties/maxProperties ignored on struct type T", "dropped"},
+		{"items", "definitions.T.a", "array a has no items, imported as an array of Any", "approximated"},
+		{"responses", "paths./x.get.responses.2XX", "ignoring response for 2XX, which overlaps the response for 200", "dropped"},
+		{"produces", "paths./x.get", "expected to produce something other than application/json: text/csv", "dropped"},
+	}
+	if compact(rep.Features) != compact(want) {
+		t.Errorf("features are %s, want %s", compact(rep.Features), compact(want))
+	}
+	_, rep = convertDoc(t, swaggerDoc(`{}`, `{"T": {"type": "string"}}`), Options{})
+	if len(rep.Features) != 0 {
+		t.Errorf("features are %s, want none", compact(rep.Features))
+	}
+}
+
+// TestAllOfExtends checks that an allOf starting with a $ref to a struct
+// extends it, and that any other allOf is flattened.
+func TestAllOfExtends(t *testing.T) {
+	base := `"Base": {"type": "object", "properties": {"id": {"type": "string"}}}`
+	checkImport(t, Options{}, []importCase{
+		{
+			definitions: `{` + base + `, "D": {"allOf": [{"$ref": "#/definitions/Base"}, {"properties": {"n": {"type": "integer"}}}]}}`,
+			types: map[string]string{
+				"D": `{"StructTypeDef":{"type":"Base","name":"D","fields":[{"name":"n","type":"Int32","optional":true}]}}`,
+			},
+		},
+		{
+			//the first member is inline, so there is no single base
+			definitions: `{` + base + `, "E": {"allOf": [{"properties": {"n": {"type": "integer"}}}, {"$ref": "#/definitions/Base"}]}}`,
+			types: map[string]string{
+				"E": `{"StructTypeDef":{"type":"Struct","name":"E","fields":[{"name":"id","type":"String","optional":true},{"name":"n","type":"Int32","optional":true}]}}`,
+			},
+			warning: "the Base in the allOf of E is flattened, not extended",
+		},
+	})
+}
+
+// TestNullEnum checks that a null in an enum is left out of its elements and
+// makes the type or field nullable, and that an enum of only null is a
+// nullable String.
+func TestNullEnum(t *testing.T) {
+	checkImport(t, Options{}, []importCase{
+		{
+			definitions: `{"E": {"type": "string", "enum": ["a", "b", null]}, "N": {"type": "string", "enum": [null]}}`,
+			types: map[string]string{
+				"E": `{"EnumTypeDef":{"type":"Enum","name":"E","annotations":{"x_nullable":"true"},"elements":[{"symbol":"a"},{"symbol":"b"}]}}`,
+				"N": `{"AliasTypeDef":{"type":"String","name":"N","annotations":{"x_nullable":"true"}}}`,
+			},
+		},
+		{
+			definitions: `{"T": {"type": "object", "properties": {"k": {"type": "string", "enum": ["x", null]}, "n": {"type": "string", "enum": [null]}}}}`,
+			types: map[string]string{
+				"T_K": `{"EnumTypeDef":{"type":"Enum","name":"T_K","elements":[{"symbol":"x"}]}}`,
+				"T_N": ``,
+				"T":   `{"StructTypeDef":{"type":"Struct","name":"T","fields":[{"name":"k","type":"T_K","optional":true,"annotations":{"x_nullable":"true"}},{"name":"n","type":"String","optional":true,"annotations":{"x_nullable":"true"}}]}}`,
+			},
+		},
+	})
+}
+
+// TestBodyAllOf checks that a body schema using allOf or oneOf is imported like
+// a definition, as a type named after the operation.
+func TestBodyAllOf(t *testing.T) {
+	body := func(schema string) string {
+		return `{"/x": {"post": {"parameters": [{"name": "body", "in": "body", "schema": ` + schema + `}], "responses": {"204": {"description": "ok"}}}}}`
+	}
+	resource := `{"type":"PostXRequest","method":"POST","path":"/x","inputs":[{"name":"body","type":"PostXRequest"}],"expected":"NO_CONTENT","name":"postX"}`
+	base := `{"Base": {"type": "object", "properties": {"id": {"type": "string"}}}}`
+	checkImport(t, Options{}, []importCase{
+		{
+			definitions: base,
+			paths:       body(`{"allOf": [{"$ref": "#/definitions/Base"}, {"properties": {"n": {"type": "integer"}}}]}`),
+			types: map[string]string{
+				"PostXRequest": `{"StructTypeDef":{"type":"Base","name":"PostXRequest","fields":[{"name":"n","type":"Int32","optional":true}]}}`,
+			},
+			resources: map[string]string{"POST /x": resource},
+		},
+		{
+			//an inline allOf
+			paths: body(`{"allOf": [{"properties": {"a": {"type": "string"}}}, {"properties": {"n": {"type": "integer"}}}]}`),
+			types: map[string]string{
+				"PostXRequest": `{"StructTypeDef":{"type":"Struct","name":"PostXRequest","fields":[{"name":"a","type":"String","optional":true},{"name":"n","type":"Int32","optional":true}]}}`,
+			},
+			resources: map[string]string{"POST /x": resource},
+		},
+		{
+			paths: body(`{"oneOf": [{"type": "string"}, {"type": "integer"}]}`),
+			types: map[string]string{
+				"PostXRequest": `{"UnionTypeDef":{"type":"Union","name":"PostXRequest","annotations":{"x_scalar_union":"true"},"variants":["String","Int32"]}}`,
+			},
+			resources: map[string]string{"POST /x": resource},
+		},
+	})
+}
+
+// TestScalarUnion checks that a value that is either an int or a string,
+// however it is said, imports as a Union annotated x_scalar_union.
+func TestScalarUnion(t *testing.T) {
+	union := func(name string) string {
+		return `{"UnionTypeDef":{"type":"Union","name":"` + name + `","annotations":{"x_scalar_union":"true"},"variants":["Int32","String"]}}`
+	}
+	checkImport(t, Options{}, []importCase{
+		{
+			definitions: `{"T": {"type": "object", "properties": {"id": {"oneOf": [{"type": "integer"}, {"type": "string"}]}, "v": {"type": "string", "format": "int-or-string"}, "w": {"x-kubernetes-int-or-string": true}}}}`,
+			types: map[string]string{
+				"T_Id": union("T_Id"),
+				"T_V":  union("T_V"),
+				"T_W":  union("T_W"),
+				"T":    `{"StructTypeDef":{"type":"Struct","name":"T","fields":[{"name":"id","type":"T_Id","optional":true},{"name":"v","type":"T_V","optional":true},{"name":"w","type":"T_W","optional":true}]}}`,
+			},
+		},
+		{
+			definitions: `{"Id": {"format": "int-or-string"}, "Key": {"anyOf": [{"type": "integer"}, {"type": "string"}]}}`,
+			types: map[string]string{
+				"Id":  union("Id"),
+				"Key": union("Key"),
+			},
+		},
+	})
+}
+
+// TestDuplicateOperationIds checks that a reused operationId gives each
+// resource after the first a name with a numeric suffix, skipping names
+// already taken, with a warning, and that the names are the same every time.
+func TestDuplicateOperationIds(t *testing.T) {
+	op := `{"operationId": "fetch", "responses": {"200": {"description": "ok", "schema": {"type": "string"}}}}`
+	paths := `{"/a": {"get": ` + op + `, "post": ` + op + `}, "/b": {"get": ` + op + `}, "/c": {"get": {"operationId": "fetch2", "responses": {"200": {"description": "ok", "schema": {"type": "string"}}}}}}`
+	tests := []struct {
+		method string
+		path   string
+		name   string
+	}{
+		{"GET", "/a", "fetch"},
+		{"POST", "/a", "fetch3"},
+		{"GET", "/b", "fetch4"},
+		{"GET", "/c", "fetch2"},
+	}
+	for i := 0; i < 5; i++ {
+		schema, rep := convertDoc(t, swaggerDoc(paths, `{}`), Options{})
+		for _, tt := range tests {
+			var r rdl.Resource
+			json.Unmarshal([]byte(resourceJSON(schema, tt.method, tt.path)), &r)
+			if string(r.Name) != tt.name {
+				t.Errorf("%s %s is named %q, want %q", tt.method, tt.path, r.Name, tt.name)
+			}
+		}
+		if !hasWarning(rep, "operationId fetch is already in use, naming the resource fetch3") || !hasWarning(rep, "operationId fetch is already in use, naming the resource fetch4") {
+			t.Errorf("warnings are %s", compact(rep.Warnings))
+		}
+	}
+}
+
+// TestObjectExamples checks that an object or array example is kept as JSON,
+// quotes and all, in its x_example annotation.
+func TestObjectExamples(t *testing.T) {
+	tests := []struct {
+		example string
+		want    string
+	}{
+		{`{"n": "say \"hi\"", "list": [1, {"a": null}]}`, `{"list":[1,{"a":null}],"n":"say \"hi\""}`},
+		{`[1, 2]`, `[1,2]`},
+		{`[]`, `[]`},
+		{`{}`, `{}`},
+	}
+	for _, tt := range tests {
+		schema, _ := convertDoc(t, swaggerDoc(`{}`, `{"T": {"type": "object", "example": `+tt.example+`}}`), Options{})
+		got := schema.Types[0].StructTypeDef.Annotations["x_example"]
+		if got != tt.want {
+			t.Errorf("%s: x_example is %s, want %s", tt.example, got, tt.want)
+		}
+		var v interface{}
+		if err := json.Unmarshal([]byte(got), &v); err != nil {
+			t.Errorf("%s: x_example is not JSON: %v", tt.example, err)
+		}
+	}
+}
+
+// TestAnnotationValue checks that objects and arrays are kept in annotations
+// as JSON, and scalars as plain strings.
+func TestAnnotationValue(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{"say \"hi\"", `say "hi"`},
+		{true, "true"},
+		{float64(3), "3"},
+		{1.5, "1.5"},
+		{[]interface{}{"a", float64(1)}, `["a",1]`},
+		{[]interface{}{}, `[]`},
+		{map[string]interface{}{"b": []interface{}{nil}, "a": "x"}, `{"a":"x","b":[null]}`},
+	}
+	for _, tt := range tests {
+		if got := annotationValue(tt.value); got != tt.want {
+			t.Errorf("%#v: annotation value is %s, want %s", tt.value, got, tt.want)
+		}
+	}
+	if got := addAnnotation(nil, "x_a", nil); got != nil {
+		t.Errorf("a nil value gave the annotations %v", got)
+	}
+}
+
+// TestAuth checks that the security schemes of an operation, or else of the
+// document, are recorded in an x_auth annotation, with where an apiKey goes
+// and its name.
+func TestAuth(t *testing.T) {
+	doc := `{"swagger": "2.0", "info": {"title": "t", "version": "1"},
+		"securityDefinitions": {"hkey": {"type": "apiKey", "in": "header", "name": "X-Key"}, "qkey": {"type": "apiKey", "in": "query", "name": "key"}, "basic": {"type": "basic"}},
+		"security": [{"hkey": []}],
+		"paths": {
+			"/x": {"get": {"security": [{"qkey": []}, {"basic": []}], "responses": {"204": {"description": "ok"}}}},
+			"/y": {"get": {"responses": {"204": {"description": "ok"}}}},
+			"/z": {"get": {"security": [], "responses": {"204": {"description": "ok"}}}}
+		}}`
+	schema, _ := convertDoc(t, doc, Options{})
+	tests := []struct {
+		path string
+		auth string
+	}{
+		{"/x", `[{"in":"query","name":"key","scheme":"qkey","type":"apiKey"},{"scheme":"basic","type":"basic"}]`},
+		{"/y", `[{"in":"header","name":"X-Key","scheme":"hkey","type":"apiKey"}]`},
+		{"/z", ``},
+	}
+	for _, tt := range tests {
+		var r rdl.Resource
+		json.Unmarshal([]byte(resourceJSON(schema, "GET", tt.path)), &r)
+		if got := r.Annotations["x_auth"]; got != tt.auth {
+			t.Errorf("%s: x_auth is %s, want %s", tt.path, got, tt.auth)
+		}
+	}
+}
+
+// TestEnumOrder checks that enum elements keep the order of the input array,
+// in defined, inline, and shared item enums alike, every time, and that
+// enums of the same values in another order are not shared.
+func TestEnumOrder(t *testing.T) {
+	definitions := `{
+		"E": {"type": "string", "enum": ["z", "m", "a", "q"]},
+		"T": {"type": "object", "properties": {"k": {"type": "string", "enum": ["q", "b", "c"]}}},
+		"A": {"type": "array", "items": {"type": "string", "enum": ["z", "m", "a"]}},
+		"B": {"type": "array", "items": {"type": "string", "enum": ["a", "m", "z"]}},
+		"C": {"type": "array", "items": {"type": "string", "enum": ["z", "m", "a"]}}
+	}`
+	for i := 0; i < 5; i++ {
+		checkImport(t, Options{}, []importCase{
+			{
+				definitions: definitions,
+				types: map[string]string{
+					"E":      `{"EnumTypeDef":{"type":"Enum","name":"E","elements":[{"symbol":"z"},{"symbol":"m"},{"symbol":"a"},{"symbol":"q"}]}}`,
+					"T_K":    `{"EnumTypeDef":{"type":"Enum","name":"T_K","elements":[{"symbol":"q"},{"symbol":"b"},{"symbol":"c"}]}}`,
+					"A_Item": `{"EnumTypeDef":{"type":"Enum","name":"A_Item","elements":[{"symbol":"z"},{"symbol":"m"},{"symbol":"a"}]}}`,
+					"B_Item": `{"EnumTypeDef":{"type":"Enum","name":"B_Item","elements":[{"symbol":"a"},{"symbol":"m"},{"symbol":"z"}]}}`,
+					"C":      `{"ArrayTypeDef":{"type":"Array","name":"C","items":"A_Item"}}`,
+					"C_Item": ``,
+				},
+			},
+		})
+	}
+}
+
+// TestMissingRequired checks the warning for a required property that is not
+// defined, and that one defined by another member of an allOf is no cause
+// for it.
+func TestMissingRequired(t *testing.T) {
+	checkImport(t, Options{}, []importCase{
+		{
+			definitions: `{"T": {"type": "object", "required": ["a", "ghost"], "properties": {"a": {"type": "string"}}}}`,
+			types: map[string]string{
+				"T": `{"StructTypeDef":{"type":"Struct","name":"T","fields":[{"name":"a","type":"String"}]}}`,
+			},
+			warning: `required property "ghost" of T is not defined`,
+		},
+		{
+			definitions: `{"Base": {"type": "object", "properties": {"id": {"type": "string"}}}, "D": {"allOf": [{"$ref": "#/definitions/Base"}, {"required": ["id", "n"], "properties": {"n": {"type": "integer"}}}]}}`,
+			types: map[string]string{
+				"D": `{"StructTypeDef":{"type":"Base","name":"D","fields":[{"name":"n","type":"Int32"}]}}`,
+			},
+		},
+		{
+			definitions: `{"E": {"allOf": [{"properties": {"x": {"type": "string"}}}, {"required": ["x"]}]}}`,
+			types: map[string]string{
+				"E": `{"StructTypeDef":{"type":"Struct","name":"E","fields":[{"name":"x","type":"String"}]}}`,
+			},
+		},
+		{
+			definitions: `{"E": {"allOf": [{"properties": {"x": {"type": "string"}}}, {"required": ["x", "y"]}]}}`,
+			warning:     `required property "y" of E is not defined`,
+		},
+	})
+}
+
+// TestDuplicateParamNames checks that parameters of the same name in different
+// places are kept apart, the one in the path binding {id}, and that one in
+// the query does not stand in for a missing path parameter.
+func TestDuplicateParamNames(t *testing.T) {
+	paths := `{"/x/{id}": {"get": {"parameters": [{"name": "id", "in": "query", "type": "integer"}, {"name": "id", "in": "path", "required": true, "type": "string"}, {"name": "id", "in": "header", "type": "boolean"}], "responses": {"204": {"description": "ok"}}}}}`
+	checkImport(t, Options{}, []importCase{
+		{
+			paths: paths,
+			resources: map[string]string{
+				"GET /x/{id}": `{"type":"Any","method":"GET","path":"/x/{id}","inputs":[{"name":"id_query","type":"Int32","queryParam":"id"},{"name":"id","type":"String","pathParam":true},{"name":"id_header","type":"Bool","header":"id"}],"expected":"NO_CONTENT","name":"getXById"}`,
+			},
+		},
+	})
+	paths = `{"/x/{id}": {"get": {"parameters": [{"name": "id", "in": "query", "type": "integer"}], "responses": {"204": {"description": "ok"}}}}}`
+	if _, err := Convert("test", []byte(swaggerDoc(paths, `{}`)), Options{}); err == nil || !strings.Contains(err.Error(), "Resource input 'id' in 'GET /x/{id}' has no corresponding type declaration") {
+		t.Errorf("a query parameter stood in for the path parameter: %v", err)
+	}
+}
+
+// TestDurationFormat checks that format duration is a String annotated with
+// its format, keeping a default.
+func TestDurationFormat(t *testing.T) {
+	checkImport(t, Options{}, []importCase{
+		{
+			definitions: `{"D": {"type": "string", "format": "duration"}, "T": {"type": "object", "properties": {"d": {"type": "string", "format": "duration", "default": "PT1H"}}}}`,
+			types: map[string]string{
+				"D": `{"AliasTypeDef":{"type":"String","name":"D","annotations":{"x_format":"duration"}}}`,
+				"T": `{"StructTypeDef":{"type":"Struct","name":"T","fields":[{"name":"d","type":"String","optional":true,"default":"PT1H","annotations":{"x_format":"duration"}}]}}`,
+			},
+		},
+	})
+}
+
+// TestSchemalessResponses checks that a success response without a schema is
+// imported as NO_CONTENT, unless another success response has a schema, which
+// then gives the type.
+func TestSchemalessResponses(t *testing.T) {
+	checkImport(t, Options{}, []importCase{
+		{
+			paths: `{"/x": {"delete": {"responses": {"204": {"description": "gone"}}}}}`,
+			resources: map[string]string{
+				"DELETE /x": `{"type":"Any","method":"DELETE","path":"/x","expected":"NO_CONTENT","name":"deleteX"}`,
+			},
+		},
+		{
+			paths: `{"/x": {"get": {"responses": {"200": {"description": "ok"}}}}}`,
+			resources: map[string]string{
+				"GET /x": `{"type":"Any","method":"GET","path":"/x","expected":"NO_CONTENT","name":"getX"}`,
+			},
+			warning: "200 response has no schema, imported as NO_CONTENT",
+		},
+		{
+			paths: `{"/x": {"patch": {"responses": {"204": {"description": "none"}, "404": {"description": "no", "schema": {"type": "integer"}}}}}}`,
+			resources: map[string]string{
+				"PATCH /x": `{"type":"Any","method":"PATCH","path":"/x","expected":"NO_CONTENT","exceptions":{"404":{"type":"Int32"}},"name":"patchX"}`,
+			},
+		},
+		{
+			paths: `{"/x": {"post": {"responses": {"201": {"description": "made", "schema": {"type": "string"}}, "204": {"description": "none"}}}}}`,
+			resources: map[string]string{
+				"POST /x": `{"type":"String","method":"POST","path":"/x","expected":"OK","alternatives":["204"],"name":"postX"}`,
+			},
+		},
+		{
+			//the 200 without a schema comes first, but does not take the type
+			paths: `{"/x": {"put": {"responses": {"200": {"description": "ok"}, "201": {"description": "made", "schema": {"type": "string"}}}}}}`,
+			resources: map[string]string{
+				"PUT /x": `{"type":"String","method":"PUT","path":"/x","expected":"OK","name":"putX"}`,
+			},
+		},
+	})
+}
+
+// TestJSONFormat checks that a string of serialized JSON, given by format json
+// or x-format json, is a String annotated x_format_json, keeping an object
+// example as JSON.
+func TestJSONFormat(t *testing.T) {
+	checkImport(t, Options{}, []importCase{
+		{
+			definitions: `{"J": {"type": "string", "format": "json"}, "K": {"type": "string", "x-format": "json"}}`,
+			types: map[string]string{
+				"J": `{"AliasTypeDef":{"type":"String","name":"J","annotations":{"x_format_json":"true"}}}`,
+				"K": `{"AliasTypeDef":{"type":"String","name":"K","annotations":{"x_format_json":"true"}}}`,
+			},
+		},
+		{
+			definitions: `{"T": {"type": "object", "properties": {"j": {"type": "string", "format": "json", "example": {"a": 1}}, "k": {"type": "string", "x-format": "json"}}}}`,
+			types: map[string]string{
+				"T":   `{"StructTypeDef":{"type":"Struct","name":"T","fields":[{"name":"j","type":"String","optional":true,"annotations":{"x_example":"{\"a\":1}","x_format_json":"true"}},{"name":"k","type":"String","optional":true,"annotations":{"x_format_json":"true"}}]}}`,
+				"T_K": ``,
+			},
+		},
+		{
+			//an x-format object is still a set of annotations
+			definitions: `{"T": {"type": "object", "properties": {"m": {"type": "string", "x-format": {"case": "upper"}}}}}`,
+			types: map[string]string{
+				"T_M": `{"AliasTypeDef":{"type":"String","name":"T_M","annotations":{"x_format_case":"upper"}}}`,
+			},
+		},
+	})
+}
+
+// TestBodyName checks that x-codegen-request-body-name names the body input,
+// unless it is not an identifier or another input has the name.
+func TestBodyName(t *testing.T) {
+	body := `{"name": "body", "in": "body", "schema": {"type": "string"}}`
+	tests := []struct {
+		name   string
+		params string
+		inputs string
+		warn   string
+	}{
+		{"pet", body, `{"name":"pet","type":"String"}`, ""},
+		{"q", `{"name": "q", "in": "query", "type": "string"}, ` + body, `{"name":"q","type":"String","queryParam":"q"},{"name":"body","type":"String"}`, `cannot name the body parameter "q", keeping "body"`},
+		{"bad name", body, `{"name":"body","type":"String"}`, `cannot name the body parameter "bad name", keeping "body"`},
+	}
+	for _, tt := range tests {
+		checkImport(t, Options{}, []importCase{
+			{
+				paths: `{"/x": {"post": {"x-codegen-request-body-name": "` + tt.name + `", "parameters": [` + tt.params + `], "responses": {"204": {"description": "ok"}}}}}`,
+				resources: map[string]string{
+					"POST /x": `{"type":"String","method":"POST","path":"/x","inputs":[` + tt.inputs + `],"expected":"NO_CONTENT","name":"postX"}`,
+				},
+				warning: tt.warn,
+			},
+		})
+	}
+}
+
+// TestEnumDefault checks the warning for a default that is not one of the enum
+// values, whether the enum is the field's own or that of the type it refers
+// to, and the hint for one that differs only in case.
+func TestEnumDefault(t *testing.T) {
+	field := func(schema string) string {
+		return `{"E": {"type": "string", "enum": ["on", "off"]}, "T": {"type": "object", "properties": {"a": ` + schema + `}}}`
+	}
+	tests := []struct {
+		schema  string
+		warning string
+	}{
+		{`{"type": "string", "enum": ["on", "off"], "default": "on"}`, ""},
+		{`{"type": "string", "enum": ["on", "off"], "default": "maybe"}`, `default "maybe" is not one of the enum values`},
+		{`{"type": "string", "enum": ["on", "off"], "default": "ON"}`, `default "ON" is not one of the enum values, which are case-sensitive: did you mean "on"?`},
+		{`{"type": "integer", "enum": [1, 2], "default": 3}`, `default 3 is not one of the enum values`},
+		{`{"$ref": "#/definitions/E", "default": "off"}`, ""},
+		{`{"$ref": "#/definitions/E", "default": "maybe"}`, `default "maybe" is not one of the enum values`},
+	}
+	for _, tt := range tests {
+		checkImport(t, Options{}, []importCase{{definitions: field(tt.schema), warning: tt.warning}})
+	}
+}
+
+// TestBase64URL checks that format base64url is Bytes with the encoding kept
+// apart from the base64 of format byte, and that a maxLength on the encoded
+// string limits the decoded bytes.
+func TestBase64URL(t *testing.T) {
+	checkImport(t, Options{}, []importCase{
+		{
+			definitions: `{"T": {"type": "object", "properties": {"u": {"type": "string", "format": "base64url"}, "b": {"type": "string", "format": "byte"}}}}`,
+			types: map[string]string{
+				"T": `{"StructTypeDef":{"type":"Struct","name":"T","fields":[{"name":"b","type":"Bytes","optional":true,"annotations":{"x_encoding":"base64"}},{"name":"u","type":"Bytes","optional":true,"annotations":{"x_encoding":"base64url"}}]}}`,
+			},
+		},
+		{
+			definitions: `{"B": {"type": "string", "format": "base64url", "maxLength": 64}, "C": {"type": "string", "format": "base64url", "maxLength": 10}}`,
+			types: map[string]string{
+				"B": `{"BytesTypeDef":{"type":"Bytes","name":"B","annotations":{"x_encoding":"base64url","x_maxLength":"64"},"maxSize":48}}`,
+				"C": `{"BytesTypeDef":{"type":"Bytes","name":"C","annotations":{"x_encoding":"base64url","x_maxLength":"10"},"maxSize":7}}`,
+			},
+		},
+	})
+}
+
+// TestNoResponses checks that an operation with empty or missing responses,
+// such as a fire-and-forget POST, is imported as NO_CONTENT with a warning.
+func TestNoResponses(t *testing.T) {
+	checkImport(t, Options{}, []importCase{
+		{
+			paths: `{"/x": {"post": {"parameters": [{"name": "body", "in": "body", "schema": {"type": "string"}}], "responses": {}}}}`,
+			resources: map[string]string{
+				"POST /x": `{"type":"String","method":"POST","path":"/x","inputs":[{"name":"body","type":"String"}],"expected":"NO_CONTENT","name":"postX"}`,
+			},
+			warning: "no responses given, imported as NO_CONTENT",
+		},
+		{
+			paths: `{"/y": {"get": {}}}`,
+			resources: map[string]string{
+				"GET /y": `{"type":"Any","method":"GET","path":"/y","expected":"NO_CONTENT","name":"getY"}`,
+			},
+			warning: "no responses given, imported as NO_CONTENT",
+		},
+	})
+}
+
+// TestRefConstraints checks that constraints beside a $ref to a string or
+// number type make a type of the field's own, extending the referenced one,
+// and are otherwise ignored with a warning.
+func TestRefConstraints(t *testing.T) {
+	definitions := `"Name": {"type": "string", "maxLength": 100, "pattern": "^[a-z]+$"}, "Age": {"type": "integer", "minimum": 0}, "Pet": {"type": "object"}`
+	checkImport(t, Options{}, []importCase{
+		{
+			definitions: `{` + definitions + `, "T": {"type": "object", "properties": {"n": {"$ref": "#/definitions/Name", "maxLength": 10}, "a": {"$ref": "#/definitions/Age", "maximum": 20}, "m": {"$ref": "#/definitions/Name"}}}}`,
+			types: map[string]string{
+				"T_N": `{"StringTypeDef":{"type":"Name","name":"T_N","maxSize":10}}`,
+				"T_A": `{"NumberTypeDef":{"type":"Age","name":"T_A","max":{"Int32":20}}}`,
+				"T_M": ``,
+				"T":   `{"StructTypeDef":{"type":"Struct","name":"T","fields":[{"name":"a","type":"T_A","optional":true},{"name":"m","type":"Name","optional":true},{"name":"n","type":"T_N","optional":true}]}}`,
+			},
+		},
+		{
+			definitions: `{` + definitions + `, "T": {"type": "object", "properties": {"p": {"$ref": "#/definitions/Pet", "maxLength": 3}}}}`,
+			types: map[string]string{
+				"T_P": ``,
+				"T":   `{"StructTypeDef":{"type":"Struct","name":"T","fields":[{"name":"p","type":"Pet","optional":true}]}}`,
+			},
+			warning: "constraints alongside the $ref to Pet, which is not a string or number type, are ignored",
+		},
+	})
+}
+
+// TestSummaryAsName checks that SummaryAsName names an operation without an
+// operationId after a short summary, and after its method and path otherwise.
+func TestSummaryAsName(t *testing.T) {
+	op := func(extra string) string {
+		return `{"get": {` + extra + `"responses": {"204": {"description": "ok"}}}}`
+	}
+	paths := `{
+		"/a": ` + op(`"summary": "List all pets.", `) + `,
+		"/b": ` + op(`"summary": "Get the very long list of everything in the whole store today", `) + `,
+		"/c": ` + op(`"summary": "3 things", `) + `,
+		"/d": ` + op(`"operationId": "dee", "summary": "List all pets", `) + `,
+		"/e": ` + op(`"summary": "List all pets", `) + `,
+		"/f": ` + op(``) + `
+	}`
+	tests := []struct {
+		path    string
+		name    string
+		without string
+	}{
+		{"/a", "listAllPets", "getA"},
+		{"/b", "getB", "getB"},
+		{"/c", "getC", "getC"},
+		{"/d", "dee", "dee"},
+		{"/e", "listAllPets2", "getE"},
+		{"/f", "getF", "getF"},
+	}
+	with, _ := convertDoc(t, swaggerDoc(paths, `{}`), Options{SummaryAsName: true})
+	without, _ := convertDoc(t, swaggerDoc(paths, `{}`), Options{})
+	for _, tt := range tests {
+		for _, c := range []struct {
+			schema *rdl.Schema
+			want   string
+		}{{with, tt.name}, {without, tt.without}} {
+			var r rdl.Resource
+			json.Unmarshal([]byte(resourceJSON(c.schema, "GET", tt.path)), &r)
+			if string(r.Name) != c.want {
+				t.Errorf("GET %s is named %q, want %q", tt.path, r.Name, c.want)
+			}
+		}
+	}
+}
+
+// TestVerboseComments checks that VerboseComments lists the descriptions of
+// the parameters that have them in the resource comment, after its summary.
+func TestVerboseComments(t *testing.T) {
+	paths := `{
+		"/x/{id}": {"post": {"summary": "Update x.", "parameters": [{"name": "id", "in": "path", "required": true, "type": "string", "description": "the id"}, {"name": "q", "in": "query", "type": "string"}, {"name": "body", "in": "body", "description": "the new\n  x", "schema": {"type": "string"}}], "responses": {"204": {"description": "ok"}}}},
+		"/y": {"get": {"parameters": [{"name": "q", "in": "query", "type": "string", "description": "query"}], "responses": {"204": {"description": "ok"}}}},
+		"/z": {"get": {"summary": "Get z.", "parameters": [{"name": "q", "in": "query", "type": "string"}], "responses": {"204": {"description": "ok"}}}}
+	}`
+	tests := []struct {
+		method  string
+		path    string
+		verbose string
+		plain   string
+	}{
+		{"POST", "/x/{id}", "Update x.\n\n- id: the id\n- body: the new x", "Update x."},
+		{"GET", "/y", "- q: query", ""},
+		{"GET", "/z", "Get z.", "Get z."},
+	}
+	verbose, _ := convertDoc(t, swaggerDoc(paths, `{}`), Options{VerboseComments: true})
+	plain, _ := convertDoc(t, swaggerDoc(paths, `{}`), Options{})
+	for _, tt := range tests {
+		for _, c := range []struct {
+			schema *rdl.Schema
+			want   string
+		}{{verbose, tt.verbose}, {plain, tt.plain}} {
+			var r rdl.Resource
+			json.Unmarshal([]byte(resourceJSON(c.schema, tt.method, tt.path)), &r)
+			if r.Comment != c.want {
+				t.Errorf("%s %s: comment is %q, want %q", tt.method, tt.path, r.Comment, c.want)
+			}
+		}
+	}
+}
+
+// TestStringFormats checks the type and x_format annotation of strings of
+// some of the registered formats, and of one that is not registered, both as
+// fields and as types of their own.
+func TestStringFormats(t *testing.T) {
+	tests := []struct {
+		format  string
+		rdlType string
+		xformat bool
+	}{
+		{"uuid", "UUID", false},
+		{"date-time", "Timestamp", false},
+		{"url", "String", true},
+		{"iri", "String", true},
+		{"email", "String", true},
+		{"ipv4", "String", true},
+		{"binary", "Bytes", true},
+		{"password", "String", true},
+		{"decimal", "String", true},
+		{"made-up", "String", true},
+	}
+	for _, tt := range tests {
+		annotations := ""
+		if tt.xformat {
+			annotations = `"annotations":{"x_format":"` + tt.format + `"}`
+		}
+		field := `{"name":"f","type":"` + tt.rdlType + `","optional":true`
+		alias := `{"AliasTypeDef":{"type":"` + tt.rdlType + `","name":"F"`
+		if annotations != "" {
+			field += "," + annotations
+			alias += "," + annotations
+		}
+		checkImport(t, Options{}, []importCase{
+			{
+				definitions: `{"T": {"type": "object", "properties": {"f": {"type": "string", "format": "` + tt.format + `"}}}, "F": {"type": "string", "format": "` + tt.format + `"}}`,
+				types: map[string]string{
+					"T": `{"StructTypeDef":{"type":"Struct","name":"T","fields":[` + field + `}]}}`,
+					"F": alias + `}}`,
+				},
+			},
+		})
+	}
+}
+
+// TestExamplePrecedence checks that a field's example is kept on the field,
+// even when it refers to a type with an example of its own, which stays on
+// the type, and that a field without one does not copy its type's.
+func TestExamplePrecedence(t *testing.T) {
+	checkImport(t, Options{}, []importCase{
+		{
+			definitions: `{"Name": {"type": "string", "example": "rex"}, "T": {"type": "object", "properties": {"n": {"$ref": "#/definitions/Name", "example": "fido"}, "m": {"$ref": "#/definitions/Name"}, "k": {"type": "string", "example": "k1"}}}}`,
+			types: map[string]string{
+				"Name": `{"AliasTypeDef":{"type":"String","name":"Name","annotations":{"x_example":"rex"}}}`,
+				"T":    `{"StructTypeDef":{"type":"Struct","name":"T","fields":[{"name":"k","type":"String","optional":true,"annotations":{"x_example":"k1"}},{"name":"m","type":"Name","optional":true},{"name":"n","type":"Name","optional":true,"annotations":{"x_example":"fido"}}]}}`,
+			},
+		},
+	})
+}
+
+// TestDeprecatedParams checks that a deprecated parameter's input is annotated
+// x_deprecated, with a warning for a path parameter, which cannot be left out.
+func TestDeprecatedParams(t *testing.T) {
+	checkImport(t, Options{}, []importCase{
+		{
+			paths: `{"/x": {"get": {"parameters": [{"name": "q", "in": "query", "type": "string", "deprecated": true}, {"name": "h", "in": "header", "type": "string", "deprecated": false}], "responses": {"204": {"description": "ok"}}}}}`,
+			resources: map[string]string{
+				"GET /x": `{"type":"Any","method":"GET","path":"/x","inputs":[{"name":"q","type":"String","queryParam":"q","annotations":{"x_deprecated":"true"}},{"name":"h","type":"String","header":"h"}],"expected":"NO_CONTENT","name":"getX"}`,
+			},
+		},
+		{
+			paths: `{"/x/{id}": {"get": {"parameters": [{"name": "id", "in": "path", "required": true, "type": "string", "deprecated": true}], "responses": {"204": {"description": "ok"}}}}}`,
+			resources: map[string]string{
+				"GET /x/{id}": `{"type":"Any","method":"GET","path":"/x/{id}","inputs":[{"name":"id","type":"String","pathParam":true,"annotations":{"x_deprecated":"true"}}],"expected":"NO_CONTENT","name":"getXById"}`,
+			},
+			warning: "path parameter id is deprecated, but cannot be left out",
+		},
+	})
+}
+
+// TestUnsupportedType checks that a definition of a type that cannot be
+// imported is left out with a warning, or fails the import with
+// FailOnUnsupportedType.
+func TestUnsupportedType(t *testing.T) {
+	definitions := `{"A": {"type": "file"}, "B": {"type": "string"}}`
+	checkImport(t, Options{}, []importCase{
+		{
+			definitions: definitions,
+			types: map[string]string{
+				"A": ``,
+				"B": `{"AliasTypeDef":{"type":"String","name":"B"}}`,
+			},
+			warning: "unsupported top level type for A",
+		},
+	})
+	checkErrors(t, Options{FailOnUnsupportedType: true}, map[string]string{
+		definitions: "definitions.A: unsupported top level type for A",
+	})
+}
+
+// TestWriteOnly checks that SplitReadOnly keeps writeOnly properties out of
+// the response type and in the request variant only, that a property both
+// readOnly and writeOnly stays in both with a warning, and that without the
+// option a type is not split.
+func TestWriteOnly(t *testing.T) {
+	user := func(extra string) string {
+		return `{"User": {"type": "object", "required": ["name"], "properties": {"id": {"type": "string", "readOnly": true}, "name": {"type": "string"}, "password": {"type": "string", "writeOnly": true}` + extra + `}}}`
+	}
+	paths := `{"/users": {"post": {"parameters": [{"name": "body", "in": "body", "schema": {"$ref": "#/definitions/User"}}], "responses": {"200": {"description": "ok", "schema": {"$ref": "#/definitions/User"}}}}}}`
+	checkImport(t, Options{SplitReadOnly: true}, []importCase{
+		{
+			definitions: user(``),
+			paths:       paths,
+			types: map[string]string{
+				"User":        `{"StructTypeDef":{"type":"Struct","name":"User","fields":[{"name":"id","type":"String","optional":true,"annotations":{"x_readOnly":"true"}},{"name":"name","type":"String"}]}}`,
+				"UserRequest": `{"StructTypeDef":{"type":"Struct","name":"UserRequest","fields":[{"name":"name","type":"String"},{"name":"password","type":"String","optional":true,"annotations":{"x_writeOnly":"true"}}]}}`,
+			},
+			resources: map[string]string{
+				"POST /users": `{"type":"User","method":"POST","path":"/users","inputs":[{"name":"body","type":"UserRequest"}],"expected":"OK","name":"postUsers"}`,
+			},
+		},
+		{
+			definitions: user(`, "odd": {"type": "string", "readOnly": true, "writeOnly": true}`),
+			paths:       paths,
+			types: map[string]string{
+				"User":        `{"StructTypeDef":{"type":"Struct","name":"User","fields":[{"name":"id","type":"String","optional":true,"annotations":{"x_readOnly":"true"}},{"name":"name","type":"String"},{"name":"odd","type":"String","optional":true,"annotations":{"x_readOnly":"true","x_writeOnly":"true"}}]}}`,
+				"UserRequest": `{"StructTypeDef":{"type":"Struct","name":"UserRequest","fields":[{"name":"name","type":"String"},{"name":"odd","type":"String","optional":true,"annotations":{"x_readOnly":"true","x_writeOnly":"true"}},{"name":"password","type":"String","optional":true,"annotations":{"x_writeOnly":"true"}}]}}`,
+			},
+			warning: "property odd is both readOnly and writeOnly, so it is kept in requests and responses",
+		},
+	})
+	checkImport(t, Options{}, []importCase{
+		{
+			definitions: user(``),
+			paths:       paths,
+			types: map[string]string{
+				"User":        `{"StructTypeDef":{"type":"Struct","name":"User","fields":[{"name":"id","type":"String","optional":true,"annotations":{"x_readOnly":"true"}},{"name":"name","type":"String"},{"name":"password","type":"String","optional":true,"annotations":{"x_writeOnly":"true"}}]}}`,
+				"UserRequest": ``,
+			},
+		},
+	})
+}
+
+// TestProducesBinary checks that an operation producing only binary media
+// types returns Bytes annotated with them, while its errors keep their JSON
+// types, and that one producing JSON as well is left alone.
+func TestProducesBinary(t *testing.T) {
+	checkImport(t, Options{}, []importCase{
+		{
+			definitions: `{"Err": {"type": "object", "properties": {"msg": {"type": "string"}}}}`,
+			paths:       `{"/img": {"get": {"produces": ["image/png"], "responses": {"200": {"description": "ok", "schema": {"type": "string", "format": "binary"}}, "404": {"description": "no", "schema": {"$ref": "#/definitions/Err"}}}}}}`,
+			resources: map[string]string{
+				"GET /img": `{"type":"Bytes","method":"GET","path":"/img","expected":"OK","exceptions":{"404":{"type":"Err"}},"annotations":{"x_produces":"image/png"},"produces":["image/png"],"name":"getImg"}`,
+			},
+		},
+		{
+			paths: `{"/n": {"delete": {"produces": ["application/octet-stream"], "responses": {"204": {"description": "gone"}}}}}`,
+			resources: map[string]string{
+				"DELETE /n": `{"type":"Any","method":"DELETE","path":"/n","expected":"NO_CONTENT","annotations":{"x_produces":"application/octet-stream"},"produces":["application/octet-stream"],"name":"deleteN"}`,
+			},
+		},
+		{
+			paths: `{"/j": {"get": {"produces": ["image/png", "application/json"], "responses": {"200": {"description": "ok", "schema": {"type": "string"}}}}}}`,
+			resources: map[string]string{
+				"GET /j": `{"type":"String","method":"GET","path":"/j","expected":"OK","produces":["image/png","application/json"],"name":"getJ"}`,
+			},
+			warning: "expected to produce something other than application/json: image/png",
+		},
+	})
+}
+
+// TestFieldCase checks that FieldCase renames fields to camel or snake
+// case, keeping the wire name of each one renamed, and leaves alone those
+// already in that case and those whose new name another field has.
+func TestFieldCase(t *testing.T) {
+	tests := []struct {
+		name  string
+		style string
+		want  string
+	}{
+		{"user_id", "camel", "userId"},
+		{"fooBar", "camel", "fooBar"},
+		{"HTTPServer", "camel", "httpServer"},
+		{"user-id", "camel", "userId"},
+		{"fooBar", "snake", "foo_bar"},
+		{"user_id", "snake", "user_id"},
+		{"HTTPServer", "snake", "http_server"},
+		{"user_id", "preserve", "user_id"},
+	}
+	for _, tt := range tests {
+		if got := fieldCase(tt.name, tt.style, nil); got != tt.want {
+			t.Errorf("fieldCase(%q, %q) = %q, want %q", tt.name, tt.style, got, tt.want)
+		}
+	}
+	if got := fieldCase("user_id", "camel", map[string]string{"id": "ID"}); got != "userID" {
+		t.Errorf("fieldCase with acronym ID = %q, want userID", got)
+	}
+	definitions := `{"T": {"type": "object", "properties": {"user_id": {"type": "string"}, "fooBar": {"type": "string"}}}}`
+	checkImport(t, Options{FieldCase: "camel"}, []importCase{
+		{
+			definitions: definitions,
+			types: map[string]string{
+				"T": `{"StructTypeDef":{"type":"Struct","name":"T","fields":[{"name":"fooBar","type":"String","optional":true},{"name":"userId","type":"String","optional":true,"annotations":{"x_json_name":"user_id"}}]}}`,
+			},
+		},
+		{
+			definitions: `{"C": {"type": "object", "properties": {"a_b": {"type": "string"}, "aB": {"type": "string"}}}}`,
+			types: map[string]string{
+				"C": `{"StructTypeDef":{"type":"Struct","name":"C","fields":[{"name":"aB","type":"String","optional":true},{"name":"a_b","type":"String","optional":true}]}}`,
+			},
+			warning: "cannot rename field to aB, which another field already has",
+		},
+	})
+	checkImport(t, Options{FieldCase: "snake"}, []importCase{
+		{
+			definitions: definitions,
+			types: map[string]string{
+				"T": `{"StructTypeDef":{"type":"Struct","name":"T","fields":[{"name":"foo_bar","type":"String","optional":true,"annotations":{"x_json_name":"fooBar"}},{"name":"user_id","type":"String","optional":true}]}}`,
+			},
+		},
+	})
+	checkErrors(t, Options{FieldCase: "kebab"}, map[string]string{definitions: "bad field case"})
+}
+
+// TestStringMinMax checks that a minimum or maximum on a string is kept as an
+// annotation, with a warning, alongside any pattern.
+func TestStringMinMax(t *testing.T) {
+	checkImport(t, Options{}, []importCase{
+		{
+			definitions: `{"S": {"type": "string", "minimum": 1}}`,
+			types:       map[string]string{"S": `{"AliasTypeDef":{"type":"String","name":"S","annotations":{"x_minimum":"1"}}}`},
+			warning:     "minimum 1 does not apply to a string, kept as x_minimum",
+		},
+		{
+			definitions: `{"S": {"type": "string", "maximum": 9.5}}`,
+			types:       map[string]string{"S": `{"AliasTypeDef":{"type":"String","name":"S","annotations":{"x_maximum":"9.5"}}}`},
+			warning:     "maximum 9.5 does not apply to a string, kept as x_maximum",
+		},
+		{
+			definitions: `{"T": {"type": "object", "properties": {"p": {"type": "string", "pattern": "^[0-9]+$", "minimum": 0}}}}`,
+			types: map[string]string{
+				"T_P": `{"StringTypeDef":{"type":"String","name":"T_P","annotations":{"x_minimum":"0"},"pattern":"^[0-9]+$"}}`,
+				"T":   `{"StructTypeDef":{"type":"Struct","name":"T","fields":[{"name":"p","type":"T_P","optional":true}]}}`,
+			},
+			warning: "minimum 0 does not apply to a string, kept as x_minimum",
+		},
+	})
+}
+
+// TestClosedStruct checks that an object with additionalProperties false is
+// annotated x_closed, even without properties, and that one allowing them is
+// not.
+func TestClosedStruct(t *testing.T) {
+	checkImport(t, Options{}, []importCase{
+		{
+			definitions: `{"C": {"type": "object", "additionalProperties": false, "properties": {"a": {"type": "string"}}}}`,
+			types:       map[string]string{"C": `{"StructTypeDef":{"type":"Struct","name":"C","annotations":{"x_closed":"true"},"fields":[{"name":"a","type":"String","optional":true}]}}`},
+		},
+		{
+			definitions: `{"E": {"type": "object", "additionalProperties": false}}`,
+			types:       map[string]string{"E": `{"StructTypeDef":{"type":"Struct","name":"E","annotations":{"x_closed":"true"},"fields":[]}}`},
+		},
+		{
+			definitions: `{"O": {"type": "object", "additionalProperties": true, "properties": {"a": {"type": "string"}}}}`,
+			types:       map[string]string{"O": `{"StructTypeDef":{"type":"Struct","name":"O","fields":[{"name":"a","type":"String","optional":true}]}}`},
+		},
+	})
+}
+
+// TestFileParameter checks that a file parameter is a Bytes input annotated
+// x_file wherever it is, with a warning if it is not in formData.
+func TestFileParameter(t *testing.T) {
+	responses := `"responses": {"200": {"description": "ok", "schema": {"type": "string"}}}`
+	checkImport(t, Options{}, []importCase{
+		{
+			paths: `{"/u": {"post": {"consumes": ["multipart/form-data"], "parameters": [{"name": "f", "in": "formData", "type": "file", "required": true}], ` + responses + `}}}`,
+			resources: map[string]string{
+				"POST /u": `{"type":"String","method":"POST","path":"/u","inputs":[{"name":"f","type":"Bytes","annotations":{"x_file":"true"}}],"expected":"OK","consumes":["multipart/form-data"],"name":"postU"}`,
+			},
+			warning: "formData parameter f imported as the request body",
+		},
+		{
+			paths: `{"/q": {"post": {"parameters": [{"name": "f", "in": "query", "type": "file"}], ` + responses + `}}}`,
+			resources: map[string]string{
+				"POST /q": `{"type":"String","method":"POST","path":"/q","inputs":[{"name":"f","type":"Bytes","queryParam":"f","annotations":{"x_file":"true"}}],"expected":"OK","name":"postQ"}`,
+			},
+			warning: "a file parameter must be in formData, not query",
+		},
+		{
+			paths: `{"/b": {"post": {"parameters": [{"name": "f", "in": "body", "schema": {"type": "file"}}], ` + responses + `}}}`,
+			resources: map[string]string{
+				"POST /b": `{"type":"String","method":"POST","path":"/b","inputs":[{"name":"f","type":"Bytes","annotations":{"x_file":"true"}}],"expected":"OK","name":"postB"}`,
+			},
+			warning: "a file parameter must be in formData, not body",
+		},
+	})
+}
+
+// TestAcronyms checks that the acronyms are rendered as given in the names
+// the importer makes up, at the start or in the middle of one, and that
+// other words are only capitalized.
+func TestAcronyms(t *testing.T) {
+	acronyms := map[string]string{"id": "ID", "url": "URL", "api": "API", "uuid": "UUID"}
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"user id", "UserID"},
+		{"api key", "APIKey"},
+		{"image/url", "ImageURL"},
+		{"uuid", "uuid"},
+		{"user name", "UserName"},
+		{"string", "String"},
+	}
+	for _, tt := range tests {
+		if got := camelize(tt.raw, acronyms); got != tt.want {
+			t.Errorf("camelize(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+	if got := camelize("user id", nil); got != "UserId" {
+		t.Errorf("camelize without acronyms = %q, want UserId", got)
+	}
+	checkImport(t, Options{Acronyms: []string{"ID", "URL", "API", "UUID"}}, []importCase{
+		{
+			definitions: `{"T": {"type": "object", "properties": {"url": {"type": "object", "properties": {"a": {"type": "string"}}}, "uuid": {"type": "string", "enum": ["a", "b"]}}}}`,
+			types: map[string]string{
+				"T_URL":  `{"StructTypeDef":{"type":"Struct","name":"T_URL","fields":[{"name":"a","type":"String","optional":true}]}}`,
+				"T_UUID": `{"EnumTypeDef":{"type":"Enum","name":"T_UUID","elements":[{"symbol":"a"},{"symbol":"b"}]}}`,
+				"T_Url":  ``,
+			},
+		},
+		{
+			paths: `{"/users/{id}/api": {"get": {"parameters": [{"name": "id", "in": "path", "type": "string", "required": true}], "responses": {"200": {"description": "ok", "schema": {"type": "string"}}}}}}`,
+			resources: map[string]string{
+				"GET /users/{id}/api": `{"type":"String","method":"GET","path":"/users/{id}/api","inputs":[{"name":"id","type":"String","pathParam":true}],"expected":"OK","name":"getUsersByIDAPI"}`,
+			},
+		},
+	})
+	checkErrors(t, Options{Acronyms: []string{"I-D"}}, map[string]string{`{}`: "bad acronym"})
+}
+
+// TestEnumDuplicates checks that a repeated enum value is dropped with a
+// warning, and that values differing only in case are kept, also with one.
+func TestEnumDuplicates(t *testing.T) {
+	checkImport(t, Options{}, []importCase{
+		{
+			definitions: `{"E": {"type": "string", "enum": ["a", "b", "a"]}}`,
+			types:       map[string]string{"E": `{"EnumTypeDef":{"type":"Enum","name":"E","elements":[{"symbol":"a"},{"symbol":"b"}]}}`},
+			warning:     `enum value "a" is repeated, keeping only the first`,
+		},
+		{
+			definitions: `{"E": {"type": "string", "enum": ["a", "b", "A"]}}`,
+			types:       map[string]string{"E": `{"EnumTypeDef":{"type":"Enum","name":"E","elements":[{"symbol":"a"},{"symbol":"b"},{"symbol":"A"}]}}`},
+			warning:     `enum values "a" and "A" differ only in case`,
+		},
+		{
+			definitions: `{"E": {"type": "string", "enum": ["a", "b"]}}`,
+			types:       map[string]string{"E": `{"EnumTypeDef":{"type":"Enum","name":"E","elements":[{"symbol":"a"},{"symbol":"b"}]}}`},
+		},
+	})
+}
+
+// TestConstraintValues checks that an x-constraint list of allowed values on
+// a number becomes an x_values annotation, floats included, leaving out with
+// a warning what does not fit the type.
+func TestConstraintValues(t *testing.T) {
+	checkImport(t, Options{}, []importCase{
+		{
+			definitions: `{"F": {"type": "number", "x-constraint": {"values": [0.5, 1, 2.25]}}}`,
+			types:       map[string]string{"F": `{"NumberTypeDef":{"type":"Float64","name":"F","annotations":{"x_values":"[0.5,1,2.25]"}}}`},
+		},
+		{
+			definitions: `{"I": {"type": "integer", "x-constraint": {"enum": [1, 2, 3]}}}`,
+			types:       map[string]string{"I": `{"NumberTypeDef":{"type":"Int32","name":"I","annotations":{"x_values":"[1,2,3]"}}}`},
+		},
+		{
+			definitions: `{"I": {"type": "integer", "x-constraint": {"values": [1, 2.5, 3]}}}`,
+			types:       map[string]string{"I": `{"NumberTypeDef":{"type":"Int32","name":"I","annotations":{"x_values":"[1,3]"}}}`},
+			warning:     "value 2.5 in the x-constraint values on I is not an integer",
+		},
+		{
+			definitions: `{"F": {"type": "number", "x-constraint": {"values": [1, "x"]}}}`,
+			types:       map[string]string{"F": `{"NumberTypeDef":{"type":"Float64","name":"F","annotations":{"x_values":"[1]"}}}`},
+			warning:     "value x in the x-constraint values on F is not a number",
+		},
+		{
+			definitions: `{"B": {"type": "integer", "x-constraint": {"values": "1,2"}}}`,
+			types:       map[string]string{"B": `{"NumberTypeDef":{"type":"Int32","name":"B"}}`},
+			warning:     "x-constraint values on B is not a list: 1,2",
+		},
+	})
+}
+
+// TestNoResources checks that NoResources imports the definitions, even
+// those only a resource refers to, and nothing from the paths.
+func TestNoResources(t *testing.T) {
+	paths := `{"/p": {"get": {"responses": {"200": {"description": "ok", "schema": {"$ref": "#/definitions/P"}}}}}, "/q": {"get": {"responses": {"200": {"description": "ok", "schema": {"type": "string"}}}}}}`
+	definitions := `{"P": {"type": "object", "properties": {"a": {"type": "string"}}}}`
+	tests := []struct {
+		name      string
+		opts      Options
+		types     string
+		resources int
+	}{
+		{"with resources", Options{}, "P", 2},
+		{"without resources", Options{NoResources: true}, "P", 0},
+	}
+	for _, tt := range tests {
+		schema, _ := convertDoc(t, swaggerDoc(paths, definitions), tt.opts)
+		if got := strings.Join(typeNames(schema), ","); got != tt.types {
+			t.Errorf("%s: types are %s, want %s", tt.name, got, tt.types)
+		}
+		if len(schema.Resources) != tt.resources {
+			t.Errorf("%s: %d resources, want %d", tt.name, len(schema.Resources), tt.resources)
+		}
+	}
+}
+
+// TestArrayWithoutItems checks that an array without items is an array of
+// Any, with a warning, unless its allOf gives the items.
+func TestArrayWithoutItems(t *testing.T) {
+	checkImport(t, Options{}, []importCase{
+		{
+			definitions: `{"A": {"type": "array"}}`,
+			types:       map[string]string{"A": `{"ArrayTypeDef":{"type":"Array","name":"A","items":"Any"}}`},
+			warning:     "array A has no items, imported as an array of Any",
+		},
+		{
+			definitions: `{"T": {"type": "object", "properties": {"l": {"type": "array"}}}}`,
+			types:       map[string]string{"T": `{"StructTypeDef":{"type":"Struct","name":"T","fields":[{"name":"l","type":"Array","optional":true,"items":"Any"}]}}`},
+			warning:     "array l has no items, imported as an array of Any",
+		},
+		{
+			definitions: `{"B": {"allOf": [{"type": "array"}, {"items": {"type": "string"}}]}}`,
+			types:       map[string]string{"B": `{"ArrayTypeDef":{"type":"Array","name":"B","items":"String"}}`},
+		},
+	})
+}
+
+// TestTimeFormat checks that format time is a String annotated
+// x_format_time, and that a default, with or without an offset, must be an
+// RFC 3339 time.
+func TestTimeFormat(t *testing.T) {
+	checkImport(t, Options{}, []importCase{
+		{
+			definitions: `{"G": {"type": "string", "format": "time"}}`,
+			types:       map[string]string{"G": `{"AliasTypeDef":{"type":"String","name":"G","annotations":{"x_format_time":"true"}}}`},
+		},
+		{
+			definitions: `{"F": {"type": "object", "properties": {"t": {"type": "string", "format": "time", "default": "10:00:00+02:00"}}}}`,
+			types:       map[string]string{"F": `{"StructTypeDef":{"type":"Struct","name":"F","fields":[{"name":"t","type":"String","optional":true,"default":"10:00:00+02:00","annotations":{"x_format_time":"true"}}]}}`},
+		},
+		{
+			definitions: `{"F": {"type": "object", "properties": {"t": {"type": "string", "format": "time", "default": "10:00:00.5Z"}}}}`,
+			types:       map[string]string{"F": `{"StructTypeDef":{"type":"Struct","name":"F","fields":[{"name":"t","type":"String","optional":true,"default":"10:00:00.5Z","annotations":{"x_format_time":"true"}}]}}`},
+		},
+		{
+			definitions: `{"F": {"type": "object", "properties": {"t": {"type": "string", "format": "time", "default": "10am"}}}}`,
+			types:       map[string]string{"F": `{"StructTypeDef":{"type":"Struct","name":"F","fields":[{"name":"t","type":"String","optional":true,"default":"10am","annotations":{"x_format_time":"true"}}]}}`},
+			warning:     `default "10am" is not an RFC 3339 time`,
+		},
+	})
+}
+
+// TestConstrainedElements checks that constrained array items and map values
+// get types of their own, named after the field or definition, so that the
+// constraints are kept.
+func TestConstrainedElements(t *testing.T) {
+	checkImport(t, Options{}, []importCase{
+		{
+			definitions: `{"T": {"type": "object", "properties": {"names": {"type": "array", "items": {"type": "string", "maxLength": 8}}}}}`,
+			types: map[string]string{
+				"T_Names_Item": `{"StringTypeDef":{"type":"String","name":"T_Names_Item","maxSize":8}}`,
+				"T_Names":      `{"ArrayTypeDef":{"type":"Array","name":"T_Names","items":"T_Names_Item"}}`,
+				"T":            `{"StructTypeDef":{"type":"Struct","name":"T","fields":[{"name":"names","type":"T_Names","optional":true}]}}`,
+			},
+		},
+		{
+			definitions: `{"T": {"type": "object", "properties": {"m": {"type": "object", "additionalProperties": {"type": "string", "pattern": "^[a-z]+$"}}}}}`,
+			types: map[string]string{
+				"T_M_Value": `{"StringTypeDef":{"type":"String","name":"T_M_Value","pattern":"^[a-z]+$"}}`,
+				"T_M":       `{"MapTypeDef":{"type":"Map","name":"T_M","keys":"String","items":"T_M_Value"}}`,
+			},
+		},
+		{
+			definitions: `{"M": {"type": "object", "additionalProperties": {"type": "integer", "minimum": 0}}}`,
+			types: map[string]string{
+				"M_Value": `{"NumberTypeDef":{"type":"Int32","name":"M_Value","min":{"Int32":0}}}`,
+				"M":       `{"MapTypeDef":{"type":"Map","name":"M","keys":"String","items":"M_Value"}}`,
+			},
+		},
+		{
+			definitions: `{"M": {"type": "object", "additionalProperties": {"type": "string"}}}`,
+			types: map[string]string{
+				"M_Value": ``,
+				"M":       `{"MapTypeDef":{"type":"Map","name":"M","keys":"String","items":"String"}}`,
+			},
+		},
+	})
+}
+
+// TestArrayBody checks that an array body is an array type named after the
+// operation, with a type made up for its items if they are an inline object.
+func TestArrayBody(t *testing.T) {
+	responses := `"responses": {"200": {"description": "ok", "schema": {"type": "string"}}}`
+	checkImport(t, Options{}, []importCase{
+		{
+			definitions: `{"Thing": {"type": "object", "properties": {"a": {"type": "string"}}}}`,
+			paths:       `{"/things": {"post": {"operationId": "addThings", "parameters": [{"name": "body", "in": "body", "schema": {"type": "array", "items": {"$ref": "#/definitions/Thing"}}}], ` + responses + `}}}`,
+			types:       map[string]string{"AddThingsRequest": `{"ArrayTypeDef":{"type":"Array","name":"AddThingsRequest","items":"Thing"}}`},
+			resources: map[string]string{
+				"POST /things": `{"type":"String","method":"POST","path":"/things","inputs":[{"name":"body","type":"AddThingsRequest"}],"expected":"OK","name":"addThings"}`,
+			},
+		},
+		{
+			paths: `{"/raw": {"post": {"parameters": [{"name": "body", "in": "body", "schema": {"type": "array", "items": {"type": "object", "properties": {"n": {"type": "integer"}}}}}], ` + responses + `}}}`,
+			types: map[string]string{
+				"PostRawRequest_Item": `{"StructTypeDef":{"type":"Struct","name":"PostRawRequest_Item","fields":[{"name":"n","type":"Int32","optional":true}]}}`,
+				"PostRawRequest":      `{"ArrayTypeDef":{"type":"Array","name":"PostRawRequest","items":"PostRawRequest_Item"}}`,
+			},
+			resources: map[string]string{
+				"POST /raw": `{"type":"String","method":"POST","path":"/raw","inputs":[{"name":"body","type":"PostRawRequest"}],"expected":"OK","name":"postRaw"}`,
+			},
+		},
+	})
+}
+
+// TestMsEnum checks that x-ms-enum names an enum field's type and gives its
+// elements, with their names and descriptions, over the raw enum; that
+// modelAsString makes it an open String; that a name already taken falls
+// back to the field's made up one; and that values that are not strings, in
+// an x-ms-enum or a plain enum, are kept as their JSON with a warning.
+func TestMsEnum(t *testing.T) {
+	checkImport(t, Options{}, []importCase{
+		{
+			definitions: `{"T": {"type": "object", "properties": {"color": {"type": "string", "enum": ["red"], "x-ms-enum": {"name": "Color", "values": [{"value": "red", "name": "Red", "description": "the red one"}, {"value": "blue"}]}}}}}`,
+			types: map[string]string{
+				"Color": `{"EnumTypeDef":{"type":"Enum","name":"Color","elements":[{"symbol":"red","comment":"the red one","annotations":{"x_name":"Red"}},{"symbol":"blue"}]}}`,
+				"T":     `{"StructTypeDef":{"type":"Struct","name":"T","fields":[{"name":"color","type":"Color","optional":true}]}}`,
+			},
+		},
+		{
+			definitions: `{"T": {"type": "object", "properties": {"kind": {"type": "string", "enum": ["a", "b"], "x-ms-enum": {"name": "Kind", "modelAsString": true}}}}}`,
+			types: map[string]string{
+				"Kind": `{"AliasTypeDef":{"type":"String","name":"Kind","annotations":{"x_values":"[\"a\",\"b\"]"}}}`,
+				"T":    `{"StructTypeDef":{"type":"Struct","name":"T","fields":[{"name":"kind","type":"Kind","optional":true}]}}`,
+			},
+		},
+		{
+			definitions: `{"Color": {"type": "string"}, "T": {"type": "object", "properties": {"c": {"type": "string", "enum": ["x"], "x-ms-enum": {"name": "Color"}}}}}`,
+			types: map[string]string{
+				"Color": `{"AliasTypeDef":{"type":"String","name":"Color"}}`,
+				"T_C":   `{"EnumTypeDef":{"type":"Enum","name":"T_C","elements":[{"symbol":"x"}]}}`,
+			},
+			warning: `x-ms-enum name "Color" is already the name of a type, using T_C`,
+		},
+		{
+			definitions: `{"T": {"type": "object", "properties": {"c": {"type": "string", "enum": ["x"], "x-ms-enum": {"name": "Code", "values": [{"value": 1, "name": "One"}, {"value": "x"}]}}}}}`,
+			types: map[string]string{
+				"Code": `{"EnumTypeDef":{"type":"Enum","name":"Code","elements":[{"symbol":"1","annotations":{"x_name":"One"}},{"symbol":"x"}]}}`,
+			},
+			warning: `enum value 1 of a string is not a string, imported as "1"`,
+		},
+		{
+			definitions: `{"E": {"type": "string", "enum": ["a", true]}}`,
+			types:       map[string]string{"E": `{"EnumTypeDef":{"type":"Enum","name":"E","elements":[{"symbol":"a"},{"symbol":"true"}]}}`},
+			warning:     `enum value true of a string is not a string, imported as "true"`,
+		},
+	})
+}
+
+// TestBooleanSubschemas checks that a true subschema allows any value, that a
+// false property is dropped, and that false and tuple items are items of any
+// type, each with a warning.
+func TestBooleanSubschemas(t *testing.T) {
+	checkImport(t, Options{}, []importCase{
+		{
+			definitions: `{"T": {"type": "object", "properties": {"a": true, "d": {}}}}`,
+			types:       map[string]string{"T": `{"StructTypeDef":{"type":"Struct","name":"T","fields":[{"name":"a","type":"Any","optional":true},{"name":"d","type":"Any","optional":true}]}}`},
+		},
+		{
+			definitions: `{"T": {"type": "object", "properties": {"a": {"type": "string"}, "b": false}}}`,
+			types:       map[string]string{"T": `{"StructTypeDef":{"type":"Struct","name":"T","fields":[{"name":"a","type":"String","optional":true}]}}`},
+			warning:     "property b is false, which allows no value",
+		},
+		{
+			definitions: `{"A": {"type": "array", "items": [{"type": "string"}, {"type": "integer"}]}}`,
+			types:       map[string]string{"A": `{"ArrayTypeDef":{"type":"Array","name":"A","items":"Any"}}`},
+			warning:     "tuple items are not supported, imported as an array of Any",
+		},
+		{
+			definitions: `{"T": {"type": "object", "properties": {"l": {"type": "array", "items": [{"type": "string"}]}}}}`,
+			types:       map[string]string{"T": `{"StructTypeDef":{"type":"Struct","name":"T","fields":[{"name":"l","type":"Array","optional":true,"items":"Any"}]}}`},
+			warning:     "tuple items are not supported, imported as an array of Any",
+		},
+		{
+			definitions: `{"A": {"type": "array", "items": true}, "M": {"type": "object", "additionalProperties": {}}}`,
+			types: map[string]string{
+				"A": `{"ArrayTypeDef":{"type":"Array","name":"A","items":"Any"}}`,
+				"M": `{"MapTypeDef":{"type":"Map","name":"M","keys":"String","items":"Any"}}`,
+			},
+		},
+		{
+			definitions: `{"A": {"type": "array", "items": false}}`,
+			types:       map[string]string{"A": `{"ArrayTypeDef":{"type":"Array","name":"A","items":"Any"}}`},
+			warning:     "items false allows only an empty array, imported as an array of Any",
+		},
+		{
+			definitions: `{"D": {"allOf": [true, {"type": "object", "properties": {"x": {"type": "string"}}}]}}`,
+			types:       map[string]string{"D": `{"StructTypeDef":{"type":"Struct","name":"D","fields":[{"name":"x","type":"String","optional":true}]}}`},
+		},
+		{
+			paths:   `{"/x": {"post": {"parameters": [{"name": "body", "in": "body", "schema": {"type": "array", "items": [{"type": "string"}]}}], "responses": {"200": {"description": "ok", "schema": {"type": "string"}}}}}}`,
+			warning: "tuple items are not supported, imported as an array of Any",
+		},
+	})
+}
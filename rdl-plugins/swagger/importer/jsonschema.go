@@ -0,0 +1,120 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ardielle/ardielle-tools/rdl-plugins/swagger"
+)
+
+//
+// Standalone JSON Schema documents (draft-07, or later with $defs) are
+// converted to swagger documents with only definitions, which are then
+// imported like any other. Swagger's schema objects are a subset of JSON
+// Schema, so the definitions carry over as they are.
+//
+
+// jsonSchemaKeywords are the keywords of a schema document that say nothing
+// about the type it describes.
+var jsonSchemaKeywords = map[string]bool{
+	"$schema":     true,
+	"$id":         true,
+	"$comment":    true,
+	"title":       true,
+	"description": true,
+	"definitions": true,
+	"$defs":       true,
+}
+
+// jsonSchemaToSwagger converts a JSON Schema document to a swagger document
+// whose definitions are those of the schema, from either definitions or
+// $defs, and the type the schema itself describes, if any. That type is named
+// after the schema's title, or failing that the name given.
+func jsonSchemaToSwagger(name string, data []byte) (*swagger.Doc, error) {
+	var root map[string]interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	if root == nil {
+		return nil, fmt.Errorf("%s: not a JSON Schema document", name)
+	}
+	title := getString(root, "title")
+	rootName := title
+	if !isIdentifier(rootName) {
+		rootName = name
+	}
+	if rootName == "" {
+		return nil, fmt.Errorf("cannot name the type the schema describes, give it a title")
+	}
+	rootName = capitalize(rootName)
+	defs := make(map[string]swagger.Type)
+	for _, key := range []string{"definitions", "$defs"} {
+		m, ok := root[key].(map[string]interface{})
+		if !ok && root[key] != nil {
+			return nil, fmt.Errorf("%s: %s is not an object", name, key)
+		}
+		for k, v := range m {
+			def, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s: definition %s is not a schema", name, k)
+			}
+			if _, ok := defs[k]; ok {
+				return nil, fmt.Errorf("%s: %s is defined in both definitions and $defs", name, k)
+			}
+			defs[k] = jsonSchemaRefs(def, rootName).(map[string]interface{})
+		}
+	}
+	rootDef := make(swagger.Type)
+	for k, v := range root {
+		if !jsonSchemaKeywords[k] {
+			rootDef[k] = v
+		}
+	}
+	if len(rootDef) > 0 {
+		if !isIdentifier(rootName) {
+			return nil, fmt.Errorf("%s: cannot name the type the schema describes, give it a title", name)
+		}
+		if _, ok := defs[rootName]; ok {
+			return nil, fmt.Errorf("%s: the type the schema describes is named %s, which is also a definition", name, rootName)
+		}
+		if d := getString(root, "description"); d != "" {
+			rootDef["description"] = d
+		}
+		defs[rootName] = jsonSchemaRefs(map[string]interface{}(rootDef), rootName).(map[string]interface{})
+	}
+	return &swagger.Doc{
+		Swagger:     "2.0",
+		Info:        &swagger.Info{Title: title},
+		Definitions: defs,
+	}, nil
+}
+
+// jsonSchemaRefs returns a copy of the schema with its $refs rewritten for
+// swagger: those into $defs point into definitions instead, and those to the
+// whole document ("#") point to the root type.
+func jsonSchemaRefs(v interface{}, rootName string) interface{} {
+	switch s := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(s))
+		for k, sv := range s {
+			m[k] = jsonSchemaRefs(sv, rootName)
+		}
+		if ref, ok := s["$ref"].(string); ok {
+			switch {
+			case ref == "#":
+				m["$ref"] = definitionRef(rootName)
+			case strings.HasPrefix(ref, "#/$defs/"):
+				m["$ref"] = "#/definitions/" + strings.TrimPrefix(ref, "#/$defs/")
+			}
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(s))
+		for i, sv := range s {
+			a[i] = jsonSchemaRefs(sv, rootName)
+		}
+		return a
+	}
+	return v
+}
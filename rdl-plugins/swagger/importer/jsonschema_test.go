@@ -0,0 +1,98 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestJSONSchema checks the types imported from standalone JSON Schema
+// documents, and the errors for those that cannot be imported.
+func TestJSONSchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		types  map[string]string
+		err    string
+	}{
+		{
+			name:   "person",
+			schema: `{"$schema": "http://json-schema.org/draft-07/schema#", "title": "Person", "type": "object", "properties": {"name": {"type": "string"}}}`,
+			types: map[string]string{
+				"Person": `{"StructTypeDef":{"type":"Struct","name":"Person","fields":[{"name":"name","type":"String","optional":true}]}}`,
+			},
+		},
+		{
+			name:   "person",
+			schema: `{"title": "A person", "description": "someone", "type": "object", "properties": {"age": {"type": "integer", "format": "int32"}}}`,
+			types: map[string]string{
+				"Person": `{"StructTypeDef":{"type":"Struct","name":"Person","comment":"someone","fields":[{"name":"age","type":"Int32","optional":true}]}}`,
+			},
+		},
+		{
+			name:   "tree",
+			schema: `{"type": "object", "properties": {"kids": {"type": "array", "items": {"$ref": "#"}}, "leaf": {"$ref": "#/$defs/Leaf"}}, "$defs": {"Leaf": {"type": "string"}}}`,
+			types: map[string]string{
+				"Leaf": `{"AliasTypeDef":{"type":"String","name":"Leaf"}}`,
+				"Tree": `{"StructTypeDef":{"type":"Struct","name":"Tree","fields":[{"name":"kids","type":"Array","optional":true,"items":"Tree"},{"name":"leaf","type":"Leaf","optional":true}]}}`,
+			},
+		},
+		{
+			name:   "defs",
+			schema: `{"definitions": {"Id": {"type": "string", "pattern": "^[a-z]+$"}}}`,
+			types: map[string]string{
+				"Id":   `{"StringTypeDef":{"type":"String","name":"Id","pattern":"^[a-z]+$"}}`,
+				"Defs": ``,
+			},
+		},
+		{
+			name:   "booleans",
+			schema: `{"title": "Thing", "type": "object", "properties": {"a": true, "b": false, "t": {"type": "array", "items": [{"type": "string"}, {"type": "integer"}]}}}`,
+			types: map[string]string{
+				"Thing": `{"StructTypeDef":{"type":"Struct","name":"Thing","fields":[{"name":"a","type":"Any","optional":true},{"name":"t","type":"Array","optional":true,"items":"Any"}]}}`,
+			},
+		},
+		{
+			name:   "",
+			schema: `{"type": "string"}`,
+			err:    "cannot name the type the schema describes",
+		},
+		{
+			name:   "my-schema",
+			schema: `{"type": "string"}`,
+			err:    "cannot name the type the schema describes",
+		},
+		{
+			name:   "dup",
+			schema: `{"definitions": {"A": {"type": "string"}}, "$defs": {"A": {"type": "string"}}}`,
+			err:    "A is defined in both definitions and $defs",
+		},
+		{
+			name:   "same",
+			schema: `{"title": "Same", "type": "string", "definitions": {"Same": {"type": "string"}}}`,
+			err:    "also a definition",
+		},
+		{
+			name:   "bad",
+			schema: `{"definitions": [1]}`,
+			err:    "definitions is not an object",
+		},
+	}
+	for _, tt := range tests {
+		schema, err := Convert(tt.name, []byte(tt.schema), Options{JSONSchema: true})
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: got error %v, want one containing %q", tt.schema, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.schema, err)
+			continue
+		}
+		for name, want := range tt.types {
+			if got := typeJSON(schema, name); got != want {
+				t.Errorf("%s: type %s is %s, want %s", tt.schema, name, got, want)
+			}
+		}
+	}
+}
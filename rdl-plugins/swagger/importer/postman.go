@@ -0,0 +1,442 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/ardielle/ardielle-tools/rdl-plugins/swagger"
+)
+
+//
+// Postman collections (v2.1) are converted to swagger documents, which are then
+// imported like any other.
+//
+
+// postmanCollection is the part of a Postman collection the importer uses.
+type postmanCollection struct {
+	Info struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Schema      string `json:"schema"`
+	} `json:"info"`
+	Item     []*postmanItem     `json:"item"`
+	Variable []*postmanKeyValue `json:"variable"`
+}
+
+// postmanItem is either a folder, with items of its own, or a request.
+type postmanItem struct {
+	Name     string           `json:"name"`
+	Item     []*postmanItem   `json:"item"`
+	Request  *postmanRequest  `json:"request"`
+	Response []*postmanSample `json:"response"`
+}
+
+type postmanRequest struct {
+	Method string             `json:"method"`
+	URL    postmanURL         `json:"url"`
+	Header []*postmanKeyValue `json:"header"`
+	Body   *postmanBody       `json:"body"`
+}
+
+// UnmarshalJSON decodes a request, which a collection may give as just the
+// URL to GET.
+func (r *postmanRequest) UnmarshalJSON(b []byte) error {
+	var raw string
+	if err := json.Unmarshal(b, &raw); err == nil {
+		r.Method = "GET"
+		r.URL.Raw = raw
+		return nil
+	}
+	type rawRequest postmanRequest
+	return json.Unmarshal(b, (*rawRequest)(r))
+}
+
+// postmanURL is a request's URL, which a collection may give as a plain string
+// or broken into its parts.
+type postmanURL struct {
+	Raw      string             `json:"raw"`
+	Path     []string           `json:"path"`
+	Query    []*postmanKeyValue `json:"query"`
+	Variable []*postmanKeyValue `json:"variable"`
+}
+
+func (u *postmanURL) UnmarshalJSON(b []byte) error {
+	var raw string
+	if err := json.Unmarshal(b, &raw); err == nil {
+		u.Raw = raw
+		return nil
+	}
+	type rawURL postmanURL
+	return json.Unmarshal(b, (*rawURL)(u))
+}
+
+type postmanKeyValue struct {
+	Key         string      `json:"key"`
+	Value       interface{} `json:"value"`
+	Description string      `json:"description"`
+	Disabled    bool        `json:"disabled"`
+}
+
+type postmanBody struct {
+	Mode       string             `json:"mode"`
+	Raw        string             `json:"raw"`
+	URLEncoded []*postmanKeyValue `json:"urlencoded"`
+	FormData   []*postmanKeyValue `json:"formdata"`
+}
+
+// postmanSample is a saved example response.
+type postmanSample struct {
+	Code int    `json:"code"`
+	Body string `json:"body"`
+}
+
+// postmanVariable matches a Postman variable reference, such as {{baseUrl}}.
+var postmanVariable = regexp.MustCompile(`{{\s*([^{}]*?)\s*}}`)
+
+// isPostmanCollection returns true if the data is a Postman collection rather
+// than a swagger document, judging by the schema its info refers to.
+func isPostmanCollection(data []byte) bool {
+	var probe struct {
+		Info struct {
+			Schema string `json:"schema"`
+		} `json:"info"`
+	}
+	if json.Unmarshal(data, &probe) != nil {
+		return false
+	}
+	return strings.Contains(probe.Info.Schema, "getpostman.com/json/collection")
+}
+
+// postmanToSwagger converts a Postman collection to a swagger document. Each
+// request becomes an operation, tagged with the folders it is in, whose body
+// and response schemas are inferred from the request's raw JSON body and its
+// first saved successful response. Path segments of the form :name or
+// {{name}} become path parameters. The variables a URL starts with, such as
+// {{baseUrl}}, are dropped, though the path of their value in the collection,
+// if any, becomes the base path.
+func postmanToSwagger(name string, data []byte) (*swagger.Doc, warnings, error) {
+	var coll postmanCollection
+	if err := json.Unmarshal(data, &coll); err != nil {
+		return nil, nil, err
+	}
+	var warned warnings
+	if !strings.Contains(coll.Info.Schema, "/v2.1") {
+		warned.add(name, "collection schema %s is not v2.1, converting it as if it were", coll.Info.Schema)
+	}
+	doc := &swagger.Doc{
+		Swagger: "2.0",
+		Info:    &swagger.Info{Title: coll.Info.Name, Description: coll.Info.Description},
+		Paths:   make(map[string]*swagger.PathItem),
+	}
+	vars := make(map[string]string)
+	for _, v := range coll.Variable {
+		if s, ok := v.Value.(string); ok {
+			vars[v.Key] = s
+		}
+	}
+	pc := &postmanConverter{doc: doc, vars: vars, opIDs: make(map[string]bool), warnings: warned}
+	pc.items(coll.Item, nil)
+	switch {
+	case pc.unsaved == 1:
+		pc.warnings.add(name, "1 request has no saved successful response to infer one from, imported as NO_CONTENT")
+	case pc.unsaved > 1:
+		pc.warnings.add(name, "%d requests have no saved successful response to infer one from, imported as NO_CONTENT", pc.unsaved)
+	}
+	return doc, pc.warnings, nil
+}
+
+type postmanConverter struct {
+	doc      *swagger.Doc
+	vars     map[string]string
+	opIDs    map[string]bool
+	warnings warnings
+
+	//the number of requests without a saved successful response
+	unsaved int
+}
+
+func (pc *postmanConverter) items(items []*postmanItem, folders []string) {
+	for _, item := range items {
+		if item.Request == nil {
+			pc.items(item.Item, append(folders[:len(folders):len(folders)], item.Name))
+			continue
+		}
+		location := strings.Join(append(folders[:len(folders):len(folders)], item.Name), ".")
+		if err := pc.request(item, folders); err != nil {
+			pc.warnings.add(location, "%v, request skipped", err)
+		}
+	}
+}
+
+func (pc *postmanConverter) request(item *postmanItem, folders []string) error {
+	req := item.Request
+	path, params, base := pc.path(req.URL)
+	if base != "" && pc.doc.BasePath == "" {
+		pc.doc.BasePath = base
+	}
+	pi := pc.doc.Paths[path]
+	if pi == nil {
+		pi = &swagger.PathItem{}
+		pc.doc.Paths[path] = pi
+	}
+	method := strings.ToUpper(req.Method)
+	if method == "" {
+		method = "GET"
+	}
+	var slot **swagger.Operation
+	switch method {
+	case "GET":
+		slot = &pi.Get
+	case "PUT":
+		slot = &pi.Put
+	case "POST":
+		slot = &pi.Post
+	case "DELETE":
+		slot = &pi.Delete
+	case "OPTIONS":
+		slot = &pi.Options
+	case "HEAD":
+		slot = &pi.Head
+	case "PATCH":
+		slot = &pi.Patch
+	default:
+		return fmt.Errorf("method %s is not supported", method)
+	}
+	if *slot != nil {
+		return fmt.Errorf("%s %s is already defined by %q", method, path, (*slot).Summary)
+	}
+	op := &swagger.Operation{
+		Summary:   item.Name,
+		Tags:      folders,
+		Responses: make(map[string]*swagger.Response),
+	}
+	if id := postmanOperationID(item.Name); id != "" && !pc.opIDs[id] {
+		pc.opIDs[id] = true
+		op.OperationID = id
+	}
+	op.Parameters = params
+	for _, q := range req.URL.Query {
+		if q.Disabled || q.Key == "" {
+			continue
+		}
+		op.Parameters = append(op.Parameters, &swagger.Parameter{Name: q.Key, In: "query", Type: "string", CollectionFormat: "csv", Description: q.Description})
+	}
+	for _, h := range req.Header {
+		if h.Disabled || h.Key == "" {
+			continue
+		}
+		switch strings.ToLower(h.Key) {
+		case "content-type":
+			if s, ok := h.Value.(string); ok && !postmanVariable.MatchString(s) {
+				op.Consumes = []string{s}
+			}
+			continue
+		case "accept":
+			if s, ok := h.Value.(string); ok && !postmanVariable.MatchString(s) {
+				op.Produces = []string{s}
+			}
+			continue
+		}
+		op.Parameters = append(op.Parameters, &swagger.Parameter{Name: h.Key, In: "header", Type: "string", CollectionFormat: "csv", Description: h.Description})
+	}
+	if body := postmanBodySchema(req.Body); body != nil {
+		body = pc.define(op.OperationID, "Request", body)
+		op.Parameters = append(op.Parameters, &swagger.Parameter{Name: "body", In: "body", Schema: body, Required: true, CollectionFormat: "csv"})
+	}
+	for _, sample := range item.Response {
+		if sample.Code < 200 || sample.Code > 299 {
+			continue
+		}
+		code := fmt.Sprint(sample.Code)
+		if op.Responses[code] != nil {
+			continue
+		}
+		resp := &swagger.Response{Description: http.StatusText(sample.Code), Schema: make(swagger.Type)}
+		var v interface{}
+		if sample.Body != "" && json.Unmarshal([]byte(sample.Body), &v) == nil {
+			resp.Schema = pc.define(op.OperationID, "Response", inferSchema(v))
+		}
+		op.Responses[code] = resp
+	}
+	if len(op.Responses) == 0 {
+		//with nothing saved, all that is known is that the request succeeds:
+		//a 204 says so without the importer warning about each one
+		op.Responses["204"] = &swagger.Response{Description: "No saved response"}
+		pc.unsaved++
+	}
+	*slot = op
+	return nil
+}
+
+// define adds an object or array schema to the definitions, named after the
+// operation, and returns a $ref to it, so that the type has a proper name
+// rather than one made up from its context. Other schemas are returned as is.
+func (pc *postmanConverter) define(opID string, suffix string, schema swagger.Type) swagger.Type {
+	if opID == "" || (schema["type"] != "object" && schema["type"] != "array") {
+		return schema
+	}
+	if pc.doc.Definitions == nil {
+		pc.doc.Definitions = make(map[string]swagger.Type)
+	}
+	name := capitalize(opID) + suffix
+	for i := 2; pc.doc.Definitions[name] != nil; i++ {
+		name = capitalize(opID) + suffix + fmt.Sprint(i)
+	}
+	pc.doc.Definitions[name] = schema
+	return swagger.Type{"$ref": definitionRef(name)}
+}
+
+// path returns the swagger path template of the URL, with its path parameters
+// and the base path given by a leading variable such as {{baseUrl}}.
+func (pc *postmanConverter) path(u postmanURL) (string, []*swagger.Parameter, string) {
+	segments := u.Path
+	base := ""
+	if segments == nil {
+		raw := u.Raw
+		if i := strings.IndexAny(raw, "?#"); i >= 0 {
+			raw = raw[:i]
+		}
+		if i := strings.Index(raw, "://"); i >= 0 {
+			raw = raw[i+3:]
+		}
+		segments = strings.Split(raw, "/")
+		//the first segment is the host, or a variable standing for it
+		base = pc.basePath(segments[0])
+		segments = segments[1:]
+	}
+	descriptions := make(map[string]string)
+	for _, v := range u.Variable {
+		descriptions[v.Key] = v.Description
+	}
+	var params []*swagger.Parameter
+	var parts []string
+	for _, seg := range segments {
+		if seg == "" {
+			continue
+		}
+		pname := ""
+		if strings.HasPrefix(seg, ":") {
+			pname = seg[1:]
+		} else if m := postmanVariable.FindStringSubmatch(seg); m != nil && m[0] == seg {
+			pname = m[1]
+		}
+		if pname == "" {
+			parts = append(parts, seg)
+			continue
+		}
+		parts = append(parts, "{"+pname+"}")
+		params = append(params, &swagger.Parameter{Name: pname, In: "path", Type: "string", Required: true, CollectionFormat: "csv", Description: descriptions[pname]})
+	}
+	return "/" + strings.Join(parts, "/"), params, base
+}
+
+// basePath returns the path of the URL a host variable such as {{baseUrl}}
+// stands for, if the collection defines it.
+func (pc *postmanConverter) basePath(host string) string {
+	m := postmanVariable.FindStringSubmatch(host)
+	if m == nil || m[0] != host {
+		return ""
+	}
+	value := pc.vars[m[1]]
+	if value == "" {
+		return ""
+	}
+	if !strings.Contains(value, "://") {
+		value = "http://" + value
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(u.Path, "/")
+}
+
+// postmanBodySchema returns the schema of a request body: inferred from raw
+// JSON, an object of strings for form data, or a string for anything else,
+// such as JSON with variables in it. A request without a body has none.
+func postmanBodySchema(body *postmanBody) swagger.Type {
+	if body == nil {
+		return nil
+	}
+	switch body.Mode {
+	case "raw":
+		if strings.TrimSpace(body.Raw) == "" {
+			return nil
+		}
+		var v interface{}
+		if json.Unmarshal([]byte(body.Raw), &v) == nil {
+			return inferSchema(v)
+		}
+		return swagger.Type{"type": "string"}
+	case "urlencoded", "formdata":
+		fields := body.URLEncoded
+		if body.Mode == "formdata" {
+			fields = body.FormData
+		}
+		props := make(map[string]interface{})
+		for _, f := range fields {
+			if !f.Disabled && f.Key != "" {
+				props[f.Key] = map[string]interface{}{"type": "string"}
+			}
+		}
+		return swagger.Type{"type": "object", "properties": props}
+	}
+	return nil
+}
+
+// inferSchema returns a schema describing the JSON value, as decoded by
+// encoding/json. An array is described by its first element. A null says
+// nothing of the type, so it allows any value, and null.
+func inferSchema(v interface{}) swagger.Type {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		props := make(map[string]interface{}, len(val))
+		for k, e := range val {
+			props[k] = map[string]interface{}(inferSchema(e))
+		}
+		return swagger.Type{"type": "object", "properties": props}
+	case []interface{}:
+		items := map[string]interface{}{}
+		if len(val) > 0 {
+			items = inferSchema(val[0])
+		}
+		return swagger.Type{"type": "array", "items": items}
+	case string:
+		return swagger.Type{"type": "string"}
+	case float64:
+		if val == math.Trunc(val) {
+			return swagger.Type{"type": "integer"}
+		}
+		return swagger.Type{"type": "number"}
+	case bool:
+		return swagger.Type{"type": "boolean"}
+	case nil:
+		return swagger.Type{"type": []interface{}{"null"}}
+	}
+	return swagger.Type{}
+}
+
+// postmanOperationID returns a lower camel case identifier made from the words
+// of a request's name, or an empty string if there are none.
+func postmanOperationID(name string) string {
+	words := strings.FieldsFunc(name, func(c rune) bool {
+		return !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9')
+	})
+	id := ""
+	for i, w := range words {
+		if i == 0 {
+			id = strings.ToLower(w[:1]) + w[1:]
+		} else {
+			id += capitalize(w)
+		}
+	}
+	if !isIdentifier(id) {
+		return ""
+	}
+	return id
+}
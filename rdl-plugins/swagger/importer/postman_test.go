@@ -0,0 +1,119 @@
+package importer
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+const postmanShop = `{
+	"info": {"name": "Shop", "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+	"variable": [{"key": "baseUrl", "value": "https://api.example.com/v1"}],
+	"item": [{"name": "Orders", "item": [
+		{
+			"name": "Get order",
+			"request": {"method": "GET", "url": {"raw": "{{baseUrl}}/orders/:orderId?verbose=true", "host": ["{{baseUrl}}"], "path": ["orders", ":orderId"], "query": [{"key": "verbose", "value": "true"}]}},
+			"response": [{"name": "ok", "code": 200, "body": "{\"id\": \"a1\", \"total\": 12.5}"}]
+		},
+		{
+			"name": "Create order",
+			"request": {"method": "POST", "header": [{"key": "Content-Type", "value": "application/json"}], "body": {"mode": "raw", "raw": "{\"sku\": \"x\", \"qty\": 1}"}, "url": {"raw": "{{baseUrl}}/orders", "host": ["{{baseUrl}}"], "path": ["orders"]}}
+		},
+		{
+			"name": "Get item",
+			"request": {"method": "GET", "url": "{{baseUrl}}/orders/{{orderId}}/items/:sku"}
+		}
+	]}]
+}`
+
+// TestPostman checks the resources imported from a small collection, whose
+// URLs start with {{baseUrl}} and have :name and {{name}} path parameters, and
+// that its requests without saved responses give a single warning.
+func TestPostman(t *testing.T) {
+	schema, rep := convertDoc(t, postmanShop, Options{})
+	if schema.Base != "/v1" {
+		t.Errorf("base is %q, want /v1", schema.Base)
+	}
+	tests := []struct {
+		method   string
+		path     string
+		name     string
+		rtype    string
+		expected string
+		inputs   []string
+	}{
+		{"GET", "/orders/{orderId}", "getOrder", "GetOrderResponse", "OK", []string{"orderId", "verbose"}},
+		{"POST", "/orders", "createOrder", "CreateOrderRequest", "NO_CONTENT", []string{"body"}},
+		{"GET", "/orders/{orderId}/items/{sku}", "getItem", "Any", "NO_CONTENT", []string{"orderId", "sku"}},
+	}
+	for _, tt := range tests {
+		j := resourceJSON(schema, tt.method, tt.path)
+		if j == "" {
+			t.Errorf("no resource %s %s", tt.method, tt.path)
+			continue
+		}
+		var r rdl.Resource
+		json.Unmarshal([]byte(j), &r)
+		var inputs []string
+		for _, in := range r.Inputs {
+			inputs = append(inputs, string(in.Name))
+		}
+		if string(r.Name) != tt.name || string(r.Type) != tt.rtype || r.Expected != tt.expected || strings.Join(inputs, ",") != strings.Join(tt.inputs, ",") {
+			t.Errorf("%s %s is %s", tt.method, tt.path, j)
+		}
+	}
+	if got := typeJSON(schema, "GetOrderResponse"); got != `{"StructTypeDef":{"type":"Struct","name":"GetOrderResponse","fields":[{"name":"id","type":"String","optional":true},{"name":"total","type":"Float32","optional":true}]}}` {
+		t.Errorf("GetOrderResponse is %s", got)
+	}
+	if len(rep.Warnings) != 1 || !hasWarning(rep, "2 requests have no saved successful response") {
+		t.Errorf("warnings are %s", compact(rep.Warnings))
+	}
+}
+
+// TestPostmanSamples checks the types inferred from saved responses with null
+// in them, which allows any value, and that a request given as a plain URL is
+// a GET of it.
+func TestPostmanSamples(t *testing.T) {
+	collection := func(request, body string) string {
+		return `{"info": {"name": "Shop", "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"}, "item": [` +
+			`{"name": "Get order", "request": ` + request + `, "response": [{"code": 200, "body": ` + body + `}]}]}`
+	}
+	request := `{"method": "GET", "url": "https://x.com/orders"}`
+	tests := []struct {
+		doc      string
+		types    map[string]string
+		resource string
+	}{
+		{
+			doc: collection(request, `"{\"id\": \"a1\", \"note\": null}"`),
+			types: map[string]string{
+				"GetOrderResponse": `{"StructTypeDef":{"type":"Struct","name":"GetOrderResponse","fields":[{"name":"id","type":"String","optional":true},{"name":"note","type":"Any","optional":true,"annotations":{"x_nullable":"true"}}]}}`,
+			},
+		},
+		{
+			doc: collection(request, `"[null]"`),
+			types: map[string]string{
+				"GetOrderResponse": `{"ArrayTypeDef":{"type":"Array","name":"GetOrderResponse","items":"Any"}}`,
+			},
+		},
+		{
+			doc:      collection(`"https://x.com/orders"`, `"{\"id\": \"a1\"}"`),
+			resource: `{"type":"GetOrderResponse","method":"GET","path":"/orders","comment":"Get order","expected":"OK","name":"getOrder"}`,
+		},
+	}
+	for _, tt := range tests {
+		schema, _ := convertDoc(t, tt.doc, Options{})
+		for name, want := range tt.types {
+			if got := typeJSON(schema, name); got != want {
+				t.Errorf("%s: type %s is %s, want %s", tt.doc, name, got, want)
+			}
+		}
+		if tt.resource != "" {
+			if got := resourceJSON(schema, "GET", "/orders"); got != tt.resource {
+				t.Errorf("%s: resource is %s, want %s", tt.doc, got, tt.resource)
+			}
+		}
+	}
+}
@@ -0,0 +1,399 @@
+package importer
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+//
+// Transformations applied to the schema after it has been built.
+//
+
+// prefixTypes prepends the prefix to the name of every type the schema defines,
+// and to every reference to one. Base types, and types such as ResourceError
+// that the schema refers to but does not define, keep their names.
+func prefixTypes(schema *rdl.Schema, prefix string) {
+	defined := make(map[string]bool)
+	for _, t := range schema.Types {
+		name, _, _ := rdl.TypeInfo(t)
+		defined[string(name)] = true
+	}
+	for _, r := range schema.Resources {
+		if r.Name == "" && defined[string(r.Type)] {
+			//keep the name implied by the unprefixed type, i.e. the operationId
+			r.Name = rdl.Identifier(strings.ToLower(r.Method) + string(r.Type))
+		}
+	}
+	renameTypes(schema, func(name string) string {
+		if defined[name] {
+			return prefix + name
+		}
+		return name
+	})
+}
+
+// renameTypes applies the rename function to the name of every type in the
+// schema and to every type reference in its types and resources.
+func renameTypes(schema *rdl.Schema, rename func(string) string) {
+	ref := func(tr rdl.TypeRef) rdl.TypeRef {
+		if tr == "" {
+			return tr
+		}
+		return rdl.TypeRef(rename(string(tr)))
+	}
+	name := func(tn rdl.TypeName) rdl.TypeName {
+		return rdl.TypeName(rename(string(tn)))
+	}
+	for _, t := range schema.Types {
+		switch t.Variant {
+		case rdl.TypeVariantStructTypeDef:
+			td := t.StructTypeDef
+			td.Name, td.Type = name(td.Name), ref(td.Type)
+			for _, f := range td.Fields {
+				f.Type, f.Items, f.Keys = ref(f.Type), ref(f.Items), ref(f.Keys)
+			}
+		case rdl.TypeVariantMapTypeDef:
+			td := t.MapTypeDef
+			td.Name, td.Type = name(td.Name), ref(td.Type)
+			td.Keys, td.Items = ref(td.Keys), ref(td.Items)
+		case rdl.TypeVariantArrayTypeDef:
+			td := t.ArrayTypeDef
+			td.Name, td.Type = name(td.Name), ref(td.Type)
+			td.Items = ref(td.Items)
+		case rdl.TypeVariantEnumTypeDef:
+			td := t.EnumTypeDef
+			td.Name, td.Type = name(td.Name), ref(td.Type)
+		case rdl.TypeVariantUnionTypeDef:
+			td := t.UnionTypeDef
+			td.Name, td.Type = name(td.Name), ref(td.Type)
+			for i, v := range td.Variants {
+				td.Variants[i] = ref(v)
+			}
+		case rdl.TypeVariantStringTypeDef:
+			td := t.StringTypeDef
+			td.Name, td.Type = name(td.Name), ref(td.Type)
+		case rdl.TypeVariantBytesTypeDef:
+			td := t.BytesTypeDef
+			td.Name, td.Type = name(td.Name), ref(td.Type)
+		case rdl.TypeVariantNumberTypeDef:
+			td := t.NumberTypeDef
+			td.Name, td.Type = name(td.Name), ref(td.Type)
+		case rdl.TypeVariantAliasTypeDef:
+			td := t.AliasTypeDef
+			td.Name, td.Type = name(td.Name), ref(td.Type)
+		}
+	}
+	for _, r := range schema.Resources {
+		r.Type = ref(r.Type)
+		for _, in := range r.Inputs {
+			in.Type = ref(in.Type)
+		}
+		for _, out := range r.Outputs {
+			out.Type = ref(out.Type)
+		}
+		for _, e := range r.Exceptions {
+			e.Type = rename(e.Type)
+		}
+	}
+}
+
+// unwrapTypes replaces each wrapper type, a struct whose only field is an
+// array, with an array of the field's items, so that everything referring to
+// the wrapper sees the list directly. The field's JSON name is kept in an
+// x_unwrapped annotation, since the JSON on the wire is still the wrapper
+// object. Types that extend Struct indirectly, or are themselves extended, are
+// left alone, as unwrapping them would change their subtypes too.
+func unwrapTypes(schema *rdl.Schema) {
+	arrays := make(map[rdl.TypeRef]*rdl.ArrayTypeDef)
+	extended := make(map[rdl.TypeRef]bool)
+	for _, t := range schema.Types {
+		switch t.Variant {
+		case rdl.TypeVariantArrayTypeDef:
+			arrays[rdl.TypeRef(t.ArrayTypeDef.Name)] = t.ArrayTypeDef
+		case rdl.TypeVariantStructTypeDef:
+			extended[t.StructTypeDef.Type] = true
+		}
+	}
+	for _, t := range schema.Types {
+		if t.Variant != rdl.TypeVariantStructTypeDef {
+			continue
+		}
+		td := t.StructTypeDef
+		if td.Type != "Struct" || len(td.Fields) != 1 || extended[rdl.TypeRef(td.Name)] {
+			continue
+		}
+		f := td.Fields[0]
+		var atype, items rdl.TypeRef
+		if f.Type == "Array" {
+			atype, items = "Array", f.Items
+			if items == "" {
+				items = "Any"
+			}
+		} else if a, ok := arrays[f.Type]; ok {
+			atype, items = f.Type, a.Items
+		} else {
+			continue
+		}
+		anno := td.Annotations
+		wire := string(f.Name)
+		for k, v := range f.Annotations {
+			if k == "x_json_name" {
+				wire = v
+				continue
+			}
+			anno = addAnnotation(anno, string(k), v)
+		}
+		anno = addAnnotation(anno, "x_unwrapped", wire)
+		comment := td.Comment
+		if comment == "" {
+			comment = f.Comment
+		}
+		t.Variant = rdl.TypeVariantArrayTypeDef
+		t.StructTypeDef = nil
+		t.ArrayTypeDef = &rdl.ArrayTypeDef{
+			Type:        atype,
+			Name:        td.Name,
+			Comment:     comment,
+			Annotations: anno,
+			Items:       items,
+		}
+	}
+}
+
+// sortTypesByDependency orders the schema's types so that each comes after
+// the types it refers to, and otherwise alphabetically. The references in a
+// cycle, such as a recursive type's reference to itself, are ignored, but a
+// type always follows its supertype.
+func sortTypesByDependency(schema *rdl.Schema) {
+	types := make(map[string]*rdl.Type)
+	var names []string
+	for _, t := range schema.Types {
+		name, _, _ := rdl.TypeInfo(t)
+		types[string(name)] = t
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	var ordered []*rdl.Type
+	const visiting, placed = 1, 2
+	state := make(map[string]int)
+	var visit func(name string) bool
+	visit = func(name string) bool {
+		switch state[name] {
+		case visiting:
+			return false
+		case placed:
+			return true
+		}
+		state[name] = visiting
+		t := types[name]
+		_, super, _ := rdl.TypeInfo(t)
+		if types[string(super)] != nil && !visit(string(super)) {
+			//the supertype is further up this cycle; place this type after it
+			delete(state, name)
+			return false
+		}
+		for _, ref := range typeReferences(t) {
+			if types[ref] != nil {
+				visit(ref)
+			}
+		}
+		state[name] = placed
+		ordered = append(ordered, t)
+		return true
+	}
+	for _, name := range names {
+		visit(name)
+	}
+	schema.Types = ordered
+}
+
+// typeReferences returns the names of the types a type definition refers to,
+// other than its supertype, in alphabetical order.
+func typeReferences(t *rdl.Type) []string {
+	var refs []rdl.TypeRef
+	switch t.Variant {
+	case rdl.TypeVariantStructTypeDef:
+		for _, f := range t.StructTypeDef.Fields {
+			refs = append(refs, f.Type, f.Items, f.Keys)
+		}
+	case rdl.TypeVariantMapTypeDef:
+		refs = append(refs, t.MapTypeDef.Keys, t.MapTypeDef.Items)
+	case rdl.TypeVariantArrayTypeDef:
+		refs = append(refs, t.ArrayTypeDef.Items)
+	case rdl.TypeVariantUnionTypeDef:
+		refs = append(refs, t.UnionTypeDef.Variants...)
+	}
+	names := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		if ref != "" {
+			names = append(names, string(ref))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// pruneTypes removes the types that no resource refers to, directly or
+// through other types.
+func pruneTypes(schema *rdl.Schema) {
+	types := make(map[string]*rdl.Type)
+	for _, t := range schema.Types {
+		name, _, _ := rdl.TypeInfo(t)
+		types[string(name)] = t
+	}
+	used := make(map[string]bool)
+	var use func(name string)
+	use = func(name string) {
+		t := types[name]
+		if t == nil || used[name] {
+			return
+		}
+		used[name] = true
+		_, super, _ := rdl.TypeInfo(t)
+		use(string(super))
+		for _, ref := range typeReferences(t) {
+			use(ref)
+		}
+	}
+	for _, r := range schema.Resources {
+		use(string(r.Type))
+		for _, in := range r.Inputs {
+			use(string(in.Type))
+		}
+		for _, out := range r.Outputs {
+			use(string(out.Type))
+		}
+		for _, e := range r.Exceptions {
+			use(e.Type)
+		}
+	}
+	kept := schema.Types[:0]
+	for _, t := range schema.Types {
+		name, _, _ := rdl.TypeInfo(t)
+		if used[string(name)] {
+			kept = append(kept, t)
+		}
+	}
+	schema.Types = kept
+}
+
+// addResourceError adds the standard RDL ResourceError type, with a code and a
+// message, if the schema refers to ResourceError without defining it. A type
+// the schema defines under another name, such as Error, is not affected.
+func addResourceError(schema *rdl.Schema) {
+	referenced := false
+	for _, t := range schema.Types {
+		name, _, _ := rdl.TypeInfo(t)
+		if name == "ResourceError" {
+			return
+		}
+		for _, ref := range typeReferences(t) {
+			if ref == "ResourceError" {
+				referenced = true
+			}
+		}
+	}
+	for _, r := range schema.Resources {
+		if r.Type == "ResourceError" {
+			referenced = true
+		}
+		for _, in := range r.Inputs {
+			if in.Type == "ResourceError" {
+				referenced = true
+			}
+		}
+		for _, out := range r.Outputs {
+			if out.Type == "ResourceError" {
+				referenced = true
+			}
+		}
+		for _, e := range r.Exceptions {
+			if e.Type == "ResourceError" {
+				referenced = true
+			}
+		}
+	}
+	if !referenced {
+		return
+	}
+	tb := rdl.NewStructTypeBuilder("Struct", "ResourceError")
+	tb.Comment("the standard error response of an RDL resource")
+	tb.Field("code", "Int32", false, nil, "the HTTP status code")
+	tb.Field("message", "String", false, nil, "")
+	schema.Types = append(schema.Types, tb.Build())
+}
+
+// inlineSingleUse folds each type the importer made up for a struct field,
+// named Parent_Field, back into the field when nothing else uses it and RDL
+// can write it inline: an alias, or a plain Array or Map with no size
+// constraints, which become the field's Array<Items> or Map<Keys,Items>. The
+// type's annotations and comment move to the field, unless it has its own.
+// Structs, enums, and constrained types cannot be written inline, and a type
+// that refers to itself cannot be folded into anything.
+func inlineSingleUse(schema *rdl.Schema) {
+	//renaming every type to itself visits each declaration and reference once
+	uses := make(map[string]int)
+	renameTypes(schema, func(name string) string {
+		uses[name]++
+		return name
+	})
+	types := make(map[string]*rdl.Type)
+	for _, t := range schema.Types {
+		name, _, _ := rdl.TypeInfo(t)
+		types[string(name)] = t
+	}
+	folded := make(map[string]bool)
+	for _, t := range schema.Types {
+		if t.Variant != rdl.TypeVariantStructTypeDef {
+			continue
+		}
+		td := t.StructTypeDef
+		for _, f := range td.Fields {
+			tname := string(f.Type)
+			ft := types[tname]
+			if ft == nil || folded[tname] || uses[tname] != 2 || !strings.HasPrefix(tname, string(td.Name)+"_") {
+				//its declaration and this field are its only two mentions
+				continue
+			}
+			var anno map[rdl.ExtendedAnnotation]string
+			var comment string
+			switch ft.Variant {
+			case rdl.TypeVariantAliasTypeDef:
+				f.Type, anno, comment = ft.AliasTypeDef.Type, ft.AliasTypeDef.Annotations, ft.AliasTypeDef.Comment
+			case rdl.TypeVariantArrayTypeDef:
+				at := ft.ArrayTypeDef
+				if at.Type != "Array" || at.Size != nil || at.MinSize != nil || at.MaxSize != nil || at.Items == rdl.TypeRef(at.Name) {
+					continue
+				}
+				f.Type, f.Items, anno, comment = "Array", at.Items, at.Annotations, at.Comment
+			case rdl.TypeVariantMapTypeDef:
+				mt := ft.MapTypeDef
+				if mt.Type != "Map" || mt.Size != nil || mt.MinSize != nil || mt.MaxSize != nil || mt.Items == rdl.TypeRef(mt.Name) {
+					continue
+				}
+				f.Type, f.Keys, f.Items, anno, comment = "Map", mt.Keys, mt.Items, mt.Annotations, mt.Comment
+			default:
+				continue
+			}
+			folded[tname] = true
+			for k, v := range anno {
+				if _, ok := f.Annotations[k]; !ok {
+					f.Annotations = addAnnotation(f.Annotations, string(k), v)
+				}
+			}
+			if f.Comment == "" {
+				f.Comment = comment
+			}
+		}
+	}
+	kept := schema.Types[:0]
+	for _, t := range schema.Types {
+		name, _, _ := rdl.TypeInfo(t)
+		if !folded[string(name)] {
+			kept = append(kept, t)
+		}
+	}
+	schema.Types = kept
+}
@@ -0,0 +1,260 @@
+package importer
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ardielle/ardielle-go/rdl"
+)
+
+// TestTypePrefix checks that TypePrefix renames every type the schema
+// defines, and every reference to one, leaving the base types and the
+// resource names alone.
+func TestTypePrefix(t *testing.T) {
+	checkImport(t, Options{TypePrefix: "Zoo"}, []importCase{
+		{
+			definitions: `{"Pet": {"type": "object", "properties": {"tags": {"type": "array", "items": {"$ref": "#/definitions/Tag"}}, "kind": {"type": "string", "enum": ["a", "b"]}}}, "Tag": {"type": "string"}}`,
+			paths:       `{"/pets/{id}": {"get": {"operationId": "getPet", "parameters": [{"name": "id", "in": "path", "required": true, "type": "string"}], "responses": {"200": {"description": "ok", "schema": {"$ref": "#/definitions/Pet"}}, "404": {"description": "no", "schema": {"$ref": "#/definitions/Tag"}}}}}}`,
+			types: map[string]string{
+				"Pet":         ``,
+				"ZooPet":      `{"StructTypeDef":{"type":"Struct","name":"ZooPet","fields":[{"name":"kind","type":"ZooPet_Kind","optional":true},{"name":"tags","type":"Array","optional":true,"items":"ZooTag"}]}}`,
+				"ZooPet_Kind": `{"EnumTypeDef":{"type":"Enum","name":"ZooPet_Kind","elements":[{"symbol":"a"},{"symbol":"b"}]}}`,
+				"ZooTag":      `{"AliasTypeDef":{"type":"String","name":"ZooTag"}}`,
+			},
+			resources: map[string]string{
+				"GET /pets/{id}": `{"type":"ZooPet","method":"GET","path":"/pets/{id}","inputs":[{"name":"id","type":"String","pathParam":true}],"expected":"OK","exceptions":{"404":{"type":"ZooTag"}},"name":"getPet"}`,
+			},
+		},
+		{
+			//the name implied by the operationId is kept when the type is renamed
+			definitions: `{"Pet": {"type": "object", "properties": {"n": {"type": "string"}}}}`,
+			paths:       `{"/pet": {"get": {"operationId": "getPet", "responses": {"200": {"description": "ok", "schema": {"$ref": "#/definitions/Pet"}}}}}}`,
+			resources: map[string]string{
+				"GET /pet": `{"type":"ZooPet","method":"GET","path":"/pet","expected":"OK","name":"getPet"}`,
+			},
+		},
+	})
+	if _, err := Convert("test", []byte(swaggerDoc(`{}`, `{}`)), Options{TypePrefix: "Zoo-"}); err == nil {
+		t.Errorf("no error for a prefix that is not an identifier")
+	}
+}
+
+// TestUnwrap checks that Unwrap turns an object whose only property is an
+// array into that array, wherever it is used, and leaves alone the objects
+// with more properties and those in an inheritance chain.
+func TestUnwrap(t *testing.T) {
+	definitions := `{
+		"Pet": {"type": "object", "properties": {"id": {"type": "string"}}},
+		"Pets": {"type": "object", "properties": {"items": {"type": "array", "items": {"$ref": "#/definitions/Pet"}}}},
+		"Ids": {"type": "array", "items": {"type": "string"}},
+		"IdList": {"type": "object", "description": "the ids", "properties": {"ids": {"$ref": "#/definitions/Ids"}}},
+		"Two": {"type": "object", "properties": {"a": {"type": "array", "items": {"type": "string"}}, "b": {"type": "string"}}},
+		"Base": {"type": "object", "properties": {"l": {"type": "array", "items": {"type": "string"}}}},
+		"Sub": {"allOf": [{"$ref": "#/definitions/Base"}, {"properties": {"m": {"type": "string"}}}]}
+	}`
+	//the wrapper is both the body and the response
+	paths := `{"/pets": {"post": {"parameters": [{"name": "body", "in": "body", "schema": {"$ref": "#/definitions/Pets"}}], "responses": {"200": {"description": "ok", "schema": {"$ref": "#/definitions/Pets"}}}}}}`
+	checkImport(t, Options{Unwrap: true}, []importCase{
+		{
+			definitions: definitions,
+			paths:       paths,
+			types: map[string]string{
+				"Pets":   `{"ArrayTypeDef":{"type":"Array","name":"Pets","annotations":{"x_unwrapped":"items"},"items":"Pet"}}`,
+				"IdList": `{"ArrayTypeDef":{"type":"Ids","name":"IdList","comment":"the ids","annotations":{"x_unwrapped":"ids"},"items":"String"}}`,
+				"Two":    `{"StructTypeDef":{"type":"Struct","name":"Two","fields":[{"name":"a","type":"Array","optional":true,"items":"String"},{"name":"b","type":"String","optional":true}]}}`,
+				"Base":   `{"StructTypeDef":{"type":"Struct","name":"Base","fields":[{"name":"l","type":"Array","optional":true,"items":"String"}]}}`,
+			},
+			resources: map[string]string{
+				"POST /pets": `{"type":"Pets","method":"POST","path":"/pets","inputs":[{"name":"body","type":"Pets"}],"expected":"OK","name":"postPets"}`,
+			},
+		},
+	})
+	checkImport(t, Options{}, []importCase{
+		{
+			definitions: definitions,
+			types: map[string]string{
+				"Pets": `{"StructTypeDef":{"type":"Struct","name":"Pets","fields":[{"name":"items","type":"Array","optional":true,"items":"Pet"}]}}`,
+			},
+		},
+	})
+}
+
+// TestDependencyOrder checks that DependencyOrder puts each type after the
+// types it refers to, including the items of its array fields, and its
+// supertype, and otherwise orders them alphabetically, a recursive type's
+// reference to itself notwithstanding.
+func TestDependencyOrder(t *testing.T) {
+	definitions := `{
+		"A": {"type": "object", "properties": {"b": {"$ref": "#/definitions/B"}, "l": {"type": "array", "items": {"$ref": "#/definitions/Item"}}}},
+		"B": {"type": "object", "properties": {"c": {"$ref": "#/definitions/C"}}},
+		"C": {"type": "string"},
+		"Item": {"type": "string"},
+		"Node": {"type": "object", "properties": {"next": {"$ref": "#/definitions/Node"}, "tag": {"$ref": "#/definitions/Tag"}}},
+		"Tag": {"type": "string"},
+		"Aa": {"allOf": [{"$ref": "#/definitions/Zz"}, {"properties": {"m": {"type": "string"}}}]},
+		"Zz": {"type": "object", "properties": {"s": {"type": "string"}}}
+	}`
+	schema, _ := convertDoc(t, swaggerDoc(`{}`, definitions), Options{DependencyOrder: true})
+	want := "C,B,Item,A,Zz,Aa,Tag,Node"
+	if got := strings.Join(typeNames(schema), ","); got != want {
+		t.Errorf("types are in the order %s, want %s", got, want)
+	}
+}
+
+// TestTagFilters checks that OnlyTags and ExcludeTags choose the
+// operations imported, an exclusion winning over an inclusion, and that the
+// types the others used are pruned, unless KeepUnused says otherwise.
+func TestTagFilters(t *testing.T) {
+	op := func(tags string, ref string) string {
+		return `{"tags": [` + tags + `], "responses": {"200": {"description": "ok", "schema": {"$ref": "#/definitions/` + ref + `"}}}}`
+	}
+	paths := `{
+		"/pets": {"get": ` + op(`"pets"`, "Pet") + `},
+		"/orders": {"get": ` + op(`"store"`, "Order") + `},
+		"/admin": {"get": ` + op(`"pets", "admin"`, "Admin") + `},
+		"/other": {"get": ` + op(``, "Other") + `}
+	}`
+	definitions := `{
+		"Pet": {"type": "object", "properties": {"tag": {"$ref": "#/definitions/Tag"}}},
+		"Tag": {"type": "string"},
+		"Order": {"type": "object", "properties": {"n": {"type": "integer"}}},
+		"Admin": {"type": "string"},
+		"Other": {"type": "string"}
+	}`
+	tests := []struct {
+		opts      Options
+		resources string
+		types     string
+	}{
+		{Options{}, "/admin,/orders,/other,/pets", "Admin,Order,Other,Pet,Tag"},
+		{Options{OnlyTags: []string{"pets"}}, "/admin,/pets", "Admin,Pet,Tag"},
+		{Options{OnlyTags: []string{"pets"}, ExcludeTags: []string{"admin"}}, "/pets", "Pet,Tag"},
+		{Options{ExcludeTags: []string{"pets"}}, "/orders,/other", "Order,Other"},
+		{Options{OnlyTags: []string{"store"}, KeepUnused: true}, "/orders", "Admin,Order,Other,Pet,Tag"},
+		{Options{OnlyTags: []string{"none"}}, "", ""},
+	}
+	for _, tt := range tests {
+		schema, _ := convertDoc(t, swaggerDoc(paths, definitions), tt.opts)
+		var resources []string
+		for _, r := range schema.Resources {
+			resources = append(resources, r.Path)
+		}
+		sort.Strings(resources)
+		types := typeNames(schema)
+		sort.Strings(types)
+		if strings.Join(resources, ",") != tt.resources || strings.Join(types, ",") != tt.types {
+			t.Errorf("only %v, exclude %v, keep unused %v: resources %v and types %v, want %s and %s", tt.opts.OnlyTags, tt.opts.ExcludeTags, tt.opts.KeepUnused, resources, types, tt.resources, tt.types)
+		}
+	}
+}
+
+// TestResourceError checks that ResourceError defines the standard
+// ResourceError when it is used, including for error responses without a
+// schema, and leaves alone a schema whose errors are of a type of its own.
+func TestResourceError(t *testing.T) {
+	resourceError := `{"StructTypeDef":{"type":"Struct","name":"ResourceError","comment":"the standard error response of an RDL resource","fields":[{"name":"code","type":"Int32","comment":"the HTTP status code"},{"name":"message","type":"String"}]}}`
+	responses := func(errors string) string {
+		return `{"/x": {"get": {"responses": {"200": {"description": "ok", "schema": {"type": "string"}}, ` + errors + `}}}}`
+	}
+	checkImport(t, Options{ResourceError: true}, []importCase{
+		{
+			paths: responses(`"404": {"description": "no", "schema": {"$ref": "#/definitions/ResourceError"}}, "500": {"description": "err"}`),
+			types: map[string]string{"ResourceError": resourceError},
+			resources: map[string]string{
+				"GET /x": `{"type":"String","method":"GET","path":"/x","expected":"OK","exceptions":{"404":{"type":"ResourceError"},"500":{"type":"ResourceError"}},"name":"getX"}`,
+			},
+		},
+		{
+			definitions: `{"Error": {"type": "object", "properties": {"msg": {"type": "string"}}}}`,
+			paths:       responses(`"404": {"description": "no", "schema": {"$ref": "#/definitions/Error"}}`),
+			types:       map[string]string{"ResourceError": ``},
+			resources: map[string]string{
+				"GET /x": `{"type":"String","method":"GET","path":"/x","expected":"OK","exceptions":{"404":{"type":"Error"}},"name":"getX"}`,
+			},
+		},
+		{
+			//a ResourceError of the document's own is the standard one
+			definitions: `{"ResourceError": {"type": "object", "properties": {"msg": {"type": "string"}}}}`,
+			paths:       responses(`"404": {"description": "no", "schema": {"$ref": "#/definitions/ResourceError"}}`),
+			types:       map[string]string{"ResourceError": resourceError},
+		},
+	})
+	checkImport(t, Options{}, []importCase{
+		{
+			paths: responses(`"404": {"description": "no", "schema": {"$ref": "#/definitions/ResourceError"}}`),
+			types: map[string]string{"ResourceError": ``},
+		},
+	})
+}
+
+// TestInlineSingleUse checks that a made up field type used once is folded
+// into its field, with its comment and annotations, where RDL can write it
+// inline, and that one used again, a struct, a constrained array, and one
+// that refers to itself are kept.
+func TestInlineSingleUse(t *testing.T) {
+	tests := []struct {
+		types  string
+		fields string
+		kept   string
+	}{
+		{
+			`{"AliasTypeDef":{"type":"String","name":"T_A","comment":"an a","annotations":{"x_format":"json"}}}`,
+			`[{"name":"a","type":"String","comment":"an a","annotations":{"x_format":"json"}}]`,
+			"T",
+		},
+		{
+			`{"ArrayTypeDef":{"type":"Array","name":"T_A","items":"String"}}`,
+			`[{"name":"a","type":"Array","items":"String"}]`,
+			"T",
+		},
+		{
+			`{"MapTypeDef":{"type":"Map","name":"T_A","keys":"String","items":"Int32"}}`,
+			`[{"name":"a","type":"Map","items":"Int32","keys":"String"}]`,
+			"T",
+		},
+		{
+			`{"StructTypeDef":{"type":"Struct","name":"T_A","fields":[{"name":"x","type":"String"}]}}`,
+			`[{"name":"a","type":"T_A"}]`,
+			"T_A,T",
+		},
+		{
+			`{"ArrayTypeDef":{"type":"Array","name":"T_A","items":"String","maxSize":3}}`,
+			`[{"name":"a","type":"T_A"}]`,
+			"T_A,T",
+		},
+		{
+			`{"ArrayTypeDef":{"type":"Array","name":"T_A","items":"T_A"}}`,
+			`[{"name":"a","type":"T_A"}]`,
+			"T_A,T",
+		},
+		{
+			`{"ArrayTypeDef":{"type":"Array","name":"T_A","items":"String"}},{"AliasTypeDef":{"type":"T_A","name":"B"}}`,
+			`[{"name":"a","type":"T_A"}]`,
+			"T_A,B,T",
+		},
+	}
+	for _, tt := range tests {
+		var schema rdl.Schema
+		doc := `{"name":"test","types":[` + tt.types + `,{"StructTypeDef":{"type":"Struct","name":"T","fields":[{"name":"a","type":"T_A"}]}}]}`
+		if err := json.Unmarshal([]byte(doc), &schema); err != nil {
+			t.Fatalf("%s: %v", tt.types, err)
+		}
+		inlineSingleUse(&schema)
+		if got := strings.Join(typeNames(&schema), ","); got != tt.kept {
+			t.Errorf("%s: types are %s, want %s", tt.types, got, tt.kept)
+		}
+		var st *rdl.StructTypeDef
+		for _, typ := range schema.Types {
+			if typ.Variant == rdl.TypeVariantStructTypeDef && typ.StructTypeDef.Name == "T" {
+				st = typ.StructTypeDef
+			}
+		}
+		if st == nil {
+			t.Errorf("%s: no struct T", tt.types)
+		} else if got := compact(st.Fields); got != tt.fields {
+			t.Errorf("%s: fields of T are %s, want %s", tt.types, got, tt.fields)
+		}
+	}
+}
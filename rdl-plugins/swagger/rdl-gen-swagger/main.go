@@ -4,7 +4,7 @@
 package main
 
 //
-// export and RDL schema to Swagger 2.0 (http://swagger.io)
+// export and RDL schema to Swagger 2.0 (http://swagger.io), or OpenAPI 3.0
 //
 
 import (
@@ -26,13 +26,21 @@ func main() {
 	pOutdir := flag.String("o", ".", "Output directory")
 	flag.String("s", "", "RDL source file")
 	basePath := flag.String("b", "", "Base path")
+	targetVersion := flag.String("target-version", "2.0", "Version of the spec to emit: 2.0 (Swagger) or 3.0 (OpenAPI)")
 	flag.Parse()
-	data, err := ioutil.ReadAll(os.Stdin)
+	var err error
+	if *targetVersion != "2.0" && *targetVersion != "3.0" {
+		err = fmt.Errorf("bad target version: %q", *targetVersion)
+	}
+	var data []byte
+	if err == nil {
+		data, err = ioutil.ReadAll(os.Stdin)
+	}
 	if err == nil {
 		var schema rdl.Schema
 		err = json.Unmarshal(data, &schema)
 		if err == nil {
-			ExportToSwagger(&schema, *pOutdir, *basePath)
+			ExportToSwagger(&schema, *pOutdir, *basePath, *targetVersion)
 			os.Exit(0)
 		}
 	}
@@ -66,14 +74,18 @@ func outputWriter(outdir string, name string, ext string) (*bufio.Writer, *os.Fi
 	return writer, f, sname, nil
 }
 
-// ExportToSwagger exports the RDL schema to Swagger 2.0 format,
+// ExportToSwagger exports the RDL schema to Swagger 2.0 format, or OpenAPI 3.0 if that is the targetVersion,
 //   and serves it up on the specified server endpoint is provided, or outputs to stdout otherwise.
-func ExportToSwagger(schema *rdl.Schema, outdir string, basePath string) error {
+func ExportToSwagger(schema *rdl.Schema, outdir string, basePath string, targetVersion string) error {
 	sname := string(schema.Name)
-	swaggerData, err := genSwagger(schema, basePath)
+	swag, err := genSwagger(schema, basePath, targetVersion)
 	if err != nil {
 		return err
 	}
+	var swaggerData interface{} = newSwaggerDoc(swag)
+	if targetVersion == "3.0" {
+		swaggerData = toOpenAPI(swag)
+	}
 	j, err := json.MarshalIndent(swaggerData, "", "    ")
 	if err != nil {
 		return err
@@ -117,7 +129,22 @@ func ExportToSwagger(schema *rdl.Schema, outdir string, basePath string) error {
 	return http.ListenAndServe(outdir, nil)
 }
 
-func genSwagger(schema *rdl.Schema, basePath string) (*swagger.Doc, error) {
+// swaggerDoc is a Swagger 2.0 document whose paths are written even when there are none, as 2.0
+// requires them. The model leaves them optional, so that a library of definitions can be imported.
+type swaggerDoc struct {
+	*swagger.Doc
+	Paths map[string]*swagger.PathItem `json:"paths"`
+}
+
+func newSwaggerDoc(swag *swagger.Doc) *swaggerDoc {
+	paths := swag.Paths
+	if paths == nil {
+		paths = make(map[string]*swagger.PathItem)
+	}
+	return &swaggerDoc{Doc: swag, Paths: paths}
+}
+
+func genSwagger(schema *rdl.Schema, basePath string, targetVersion string) (*swagger.Doc, error) {
 	reg := rdl.NewTypeRegistry(schema)
 	sname := string(schema.Name)
 	swag := new(swagger.Doc)
@@ -231,6 +258,13 @@ func genSwagger(schema *rdl.Schema, basePath string) (*swagger.Doc, error) {
 					param.Type = ptype
 					param.Format = pformat
 					param.Schema = ref
+					if param.In != "body" && reg.FindBaseType(in.Type) == rdl.BaseTypeArray {
+						//only the body has a schema, other parameters say what their array holds in items
+						param.Type = "array"
+						param.Schema = nil
+						param.Items = makeSwaggerParamItems(reg, in.Type)
+						param.CollectionFormat = "csv"
+					}
 
 					if strings.Contains(in.QueryParam, "[]") {
 						param.CollectionFormat = "multi"
@@ -280,7 +314,7 @@ func genSwagger(schema *rdl.Schema, basePath string) (*swagger.Doc, error) {
 	if len(schema.Types) > 0 {
 		defs := make(map[string]swagger.Type)
 		for _, t := range schema.Types {
-			ref := makeSwaggerTypeDef(reg, t)
+			ref := makeSwaggerTypeDef(reg, t, targetVersion)
 			if ref != nil {
 				tName, _, _ := rdl.TypeInfo(t)
 				defs[string(tName)] = ref
@@ -346,7 +380,25 @@ func makeSwaggerTypeRef(reg rdl.TypeRegistry, itemTypeName rdl.TypeRef) (string,
 	}
 }
 
-func makeSwaggerTypeDef(reg rdl.TypeRegistry, t *rdl.Type) swagger.Type {
+// makeSwaggerParamItems returns the items of an array parameter, which are strings if the array
+// type does not say.
+func makeSwaggerParamItems(reg rdl.TypeRegistry, arrayTypeName rdl.TypeRef) swagger.Type {
+	itemTypeName := rdl.TypeRef("String")
+	if t := reg.FindType(arrayTypeName); t != nil && t.Variant == rdl.TypeVariantArrayTypeDef && t.ArrayTypeDef.Items != "" {
+		itemTypeName = t.ArrayTypeDef.Items
+	}
+	itype, iformat, ref := makeSwaggerTypeRef(reg, itemTypeName)
+	if ref != nil {
+		return ref
+	}
+	items := swagger.Type{"type": itype}
+	if iformat != "" {
+		items["format"] = iformat
+	}
+	return items
+}
+
+func makeSwaggerTypeDef(reg rdl.TypeRegistry, t *rdl.Type, targetVersion string) swagger.Type {
 	st := make(swagger.Type)
 	bt := reg.BaseType(t)
 	switch t.Variant {
@@ -458,7 +510,22 @@ func makeSwaggerTypeDef(reg rdl.TypeRegistry, t *rdl.Type) swagger.Type {
 		st["enum"] = tmp
 	case rdl.TypeVariantUnionTypeDef:
 		typedef := t.UnionTypeDef
-		fmt.Println("[" + typedef.Name + ": Swagger doesn't support unions]")
+		if targetVersion != "3.0" {
+			fmt.Fprintln(os.Stderr, "WARNING: "+typedef.Name+": Swagger 2.0 doesn't support unions, use -target-version 3.0 for a oneOf")
+			break
+		}
+		var variants []swagger.Type
+		for _, v := range typedef.Variants {
+			vtype, vformat, ref := makeSwaggerTypeRef(reg, v)
+			if ref == nil {
+				ref = swagger.Type{"type": vtype}
+				if vformat != "" {
+					ref["format"] = vformat
+				}
+			}
+			variants = append(variants, ref)
+		}
+		st["oneOf"] = variants
 	default:
 		switch bt {
 		case rdl.BaseTypeString, rdl.BaseTypeInt16, rdl.BaseTypeInt32, rdl.BaseTypeInt64, rdl.BaseTypeFloat32, rdl.BaseTypeFloat64:
@@ -469,3 +536,259 @@ func makeSwaggerTypeDef(reg rdl.TypeRegistry, t *rdl.Type) swagger.Type {
 	}
 	return st
 }
+
+// toOpenAPI converts the generated Swagger 2.0 document to OpenAPI 3.0: the definitions become
+// components.schemas, the security definitions components.securitySchemes, the schemes, host
+// and base path the servers, and body or formData parameters a requestBody, with the media types moved from
+// consumes and produces into the content of the body and responses.
+func toOpenAPI(swag *swagger.Doc) map[string]interface{} {
+	doc := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info":    swag.Info,
+	}
+	if servers := openAPIServers(swag); len(servers) > 0 {
+		doc["servers"] = servers
+	}
+	if len(swag.Security) > 0 {
+		doc["security"] = swag.Security
+	}
+	paths := make(map[string]interface{})
+	for path, item := range swag.Paths {
+		ops := make(map[string]interface{})
+		for meth, op := range map[string]*swagger.Operation{"get": item.Get, "put": item.Put, "post": item.Post, "delete": item.Delete, "options": item.Options, "head": item.Head, "patch": item.Patch} {
+			if op != nil {
+				ops[meth] = openAPIOperation(op)
+			}
+		}
+		paths[path] = ops
+	}
+	doc["paths"] = paths
+	components := make(map[string]interface{})
+	if len(swag.Definitions) > 0 {
+		schemas := make(map[string]interface{})
+		for name, def := range swag.Definitions {
+			schemas[name] = openAPIRefs(map[string]interface{}(def))
+		}
+		components["schemas"] = schemas
+	}
+	if len(swag.SecurityDefinitions) > 0 {
+		schemes := make(map[string]interface{})
+		for name, def := range swag.SecurityDefinitions {
+			schemes[name] = openAPISecurityScheme(def)
+		}
+		components["securitySchemes"] = schemes
+	}
+	if len(components) > 0 {
+		doc["components"] = components
+	}
+	return doc
+}
+
+// openAPIServers returns a server for each of the document's schemes, at its host and base path.
+// Without schemes the server URL is scheme relative, as in Swagger 2.0 the scheme defaults to the
+// one used to fetch the document, and without a host it is just the base path.
+func openAPIServers(swag *swagger.Doc) []interface{} {
+	var urls []string
+	switch {
+	case swag.Host == "":
+		if swag.BasePath != "" {
+			urls = append(urls, swag.BasePath)
+		}
+	case len(swag.Schemes) == 0:
+		urls = append(urls, "//"+swag.Host+swag.BasePath)
+	default:
+		for _, scheme := range swag.Schemes {
+			urls = append(urls, scheme+"://"+swag.Host+swag.BasePath)
+		}
+	}
+	var servers []interface{}
+	for _, url := range urls {
+		servers = append(servers, map[string]interface{}{"url": url})
+	}
+	return servers
+}
+
+// openAPISecurityScheme converts a Swagger 2.0 security definition to an OpenAPI 3.0 security
+// scheme, in which basic authentication is a scheme of type http.
+func openAPISecurityScheme(def *swagger.SecurityDef) map[string]interface{} {
+	if def.Type == "basic" {
+		return map[string]interface{}{"type": "http", "scheme": "basic"}
+	}
+	s := map[string]interface{}{"type": def.Type}
+	if def.In != "" {
+		s["in"] = def.In
+	}
+	if def.Name != "" {
+		s["name"] = def.Name
+	}
+	return s
+}
+
+// openAPIOperation converts a Swagger 2.0 operation to OpenAPI 3.0. The body parameter, or the
+// formData parameters as the properties of an object, become the requestBody, and the
+// collection formats of array parameters their style.
+func openAPIOperation(op *swagger.Operation) map[string]interface{} {
+	o := make(map[string]interface{})
+	if op.OperationID != "" {
+		o["operationId"] = op.OperationID
+	}
+	if len(op.Tags) > 0 {
+		o["tags"] = op.Tags
+	}
+	if op.Summary != "" {
+		o["summary"] = op.Summary
+	}
+	if len(op.Security) > 0 {
+		o["security"] = op.Security
+	}
+	var params []interface{}
+	form := map[string]interface{}{"type": "object"}
+	formProps := make(map[string]interface{})
+	var formRequired []string
+	formMediaType := "application/x-www-form-urlencoded"
+	for _, param := range op.Parameters {
+		schema := openAPIRefs(map[string]interface{}(param.Schema))
+		if len(param.Schema) == 0 {
+			s := map[string]interface{}{"type": param.Type}
+			if param.Format != "" {
+				s["format"] = param.Format
+			}
+			if len(param.Items) > 0 {
+				s["items"] = openAPIRefs(param.Items)
+			}
+			if param.Type == "file" {
+				//files are binary strings, which only a multipart body can carry
+				s = map[string]interface{}{"type": "string", "format": "binary"}
+				formMediaType = "multipart/form-data"
+			}
+			schema = s
+		}
+		if param.In == "body" {
+			body := map[string]interface{}{
+				"content": map[string]interface{}{mediaType(op.Consumes): map[string]interface{}{"schema": schema}},
+			}
+			if param.Required {
+				body["required"] = true
+			}
+			if param.Description != "" {
+				body["description"] = param.Description
+			}
+			o["requestBody"] = body
+			continue
+		}
+		if param.In == "formData" {
+			if param.Description != "" {
+				schema.(map[string]interface{})["description"] = param.Description
+			}
+			formProps[param.Name] = schema
+			if param.Required {
+				formRequired = append(formRequired, param.Name)
+			}
+			continue
+		}
+		p := map[string]interface{}{
+			"name":   param.Name,
+			"in":     param.In,
+			"schema": schema,
+		}
+		if param.Required {
+			p["required"] = true
+		}
+		if param.Description != "" {
+			p["description"] = param.Description
+		}
+		switch param.CollectionFormat {
+		case "multi":
+			p["style"] = "form"
+			p["explode"] = true
+		case "ssv":
+			p["style"] = "spaceDelimited"
+			p["explode"] = false
+		case "pipes":
+			p["style"] = "pipeDelimited"
+			p["explode"] = false
+		default:
+			//csv, the default, is the default style of path and header parameters, but query
+			//parameters are exploded unless they say otherwise. tsv has no 3.0 equivalent.
+			if param.Type == "array" && param.In == "query" {
+				p["style"] = "form"
+				p["explode"] = false
+			}
+		}
+		params = append(params, p)
+	}
+	if len(params) > 0 {
+		o["parameters"] = params
+	}
+	if len(formProps) > 0 {
+		for _, t := range op.Consumes {
+			if t == "multipart/form-data" || t == "application/x-www-form-urlencoded" {
+				formMediaType = t
+			}
+		}
+		form["properties"] = formProps
+		body := map[string]interface{}{
+			"content": map[string]interface{}{formMediaType: map[string]interface{}{"schema": form}},
+		}
+		if len(formRequired) > 0 {
+			form["required"] = formRequired
+			body["required"] = true
+		}
+		o["requestBody"] = body
+	}
+	responses := make(map[string]interface{})
+	for code, resp := range op.Responses {
+		r := map[string]interface{}{"description": resp.Description}
+		if len(resp.Schema) > 0 {
+			r["content"] = map[string]interface{}{mediaType(op.Produces): map[string]interface{}{"schema": openAPIRefs(map[string]interface{}(resp.Schema))}}
+		}
+		responses[code] = r
+	}
+	o["responses"] = responses
+	return o
+}
+
+// mediaType returns the first of the media types, or application/json if there are none.
+func mediaType(types []string) string {
+	if len(types) > 0 {
+		return types[0]
+	}
+	return "application/json"
+}
+
+// openAPIRefs returns a copy of the schema with its $refs to definitions pointing into
+// components.schemas instead.
+func openAPIRefs(v interface{}) interface{} {
+	switch s := v.(type) {
+	case swagger.Type:
+		return openAPIRefs(map[string]interface{}(s))
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(s))
+		for k, sv := range s {
+			m[k] = openAPIRefs(sv)
+		}
+		if ref, ok := s["$ref"].(string); ok && strings.HasPrefix(ref, "#/definitions/") {
+			m["$ref"] = "#/components/schemas/" + strings.TrimPrefix(ref, "#/definitions/")
+		}
+		return m
+	case map[string]swagger.Type:
+		m := make(map[string]interface{}, len(s))
+		for k, sv := range s {
+			m[k] = openAPIRefs(sv)
+		}
+		return m
+	case []swagger.Type:
+		a := make([]interface{}, len(s))
+		for i, sv := range s {
+			a[i] = openAPIRefs(sv)
+		}
+		return a
+	case []interface{}:
+		a := make([]interface{}, len(s))
+		for i, sv := range s {
+			a[i] = openAPIRefs(sv)
+		}
+		return a
+	}
+	return v
+}
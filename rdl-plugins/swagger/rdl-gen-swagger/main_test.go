@@ -0,0 +1,305 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/ardielle/ardielle-tools/rdl-plugins/swagger"
+)
+
+// plain returns the value as it would be read back from its JSON.
+func plain(t *testing.T, v interface{}) interface{} {
+	t.Helper()
+	j, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var p interface{}
+	if err := json.Unmarshal(j, &p); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// loadSchema parses testdata/store.rdl.
+func loadSchema(t *testing.T) *rdl.Schema {
+	t.Helper()
+	schema, err := rdl.ParseRDLFile("testdata/store.rdl", false, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schema
+}
+
+// TestOpenAPI exports the same schema as Swagger 2.0 and OpenAPI 3.0 and
+// checks that the 3.0 document says the same as the 2.0 one.
+func TestOpenAPI(t *testing.T) {
+	schema := loadSchema(t)
+	swag2, err := genSwagger(schema, "", "2.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	swag3, err := genSwagger(schema, "", "3.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, swag := range []*swagger.Doc{swag2, swag3} {
+		swag.Host = "api.example.com"
+		swag.Schemes = []string{"https"}
+		swag.SecurityDefinitions = map[string]*swagger.SecurityDef{"key": {Type: "apiKey", In: "header", Name: "X-Key"}}
+	}
+	doc := plain(t, toOpenAPI(swag3)).(map[string]interface{})
+	v2 := plain(t, swag2).(map[string]interface{})
+
+	if doc["openapi"] != "3.0.3" {
+		t.Errorf("openapi is %v", doc["openapi"])
+	}
+	if !reflect.DeepEqual(doc["info"], v2["info"]) {
+		t.Errorf("info is %v, want %v", doc["info"], v2["info"])
+	}
+	wantServers := []interface{}{map[string]interface{}{"url": "https://api.example.com/store/v1"}}
+	if !reflect.DeepEqual(doc["servers"], wantServers) {
+		t.Errorf("servers are %v, want %v", doc["servers"], wantServers)
+	}
+	components := doc["components"].(map[string]interface{})
+	wantSchemes := map[string]interface{}{"key": map[string]interface{}{"type": "apiKey", "in": "header", "name": "X-Key"}}
+	if !reflect.DeepEqual(components["securitySchemes"], wantSchemes) {
+		t.Errorf("security schemes are %v, want %v", components["securitySchemes"], wantSchemes)
+	}
+
+	//the schemas are the definitions with their refs moved, except that unions
+	//can be written as oneOf in 3.0
+	schemas := components["schemas"].(map[string]interface{})
+	defs := v2["definitions"].(map[string]interface{})
+	if len(schemas) != len(defs) {
+		t.Errorf("%d schemas, want %d", len(schemas), len(defs))
+	}
+	for name, def := range defs {
+		want := plain(t, openAPIRefs(def))
+		if name == "Code" {
+			if schemas[name].(map[string]interface{})["oneOf"] == nil {
+				t.Errorf("union %s is %v, want a oneOf", name, schemas[name])
+			}
+			continue
+		}
+		if !reflect.DeepEqual(schemas[name], want) {
+			t.Errorf("schema %s is %v, want %v", name, schemas[name], want)
+		}
+	}
+
+	//each operation has the same parameters, with the body as the requestBody,
+	//and the same responses, with their schemas as content
+	paths := doc["paths"].(map[string]interface{})
+	for path, item := range v2["paths"].(map[string]interface{}) {
+		for method, op := range item.(map[string]interface{}) {
+			op2 := op.(map[string]interface{})
+			op3, ok := paths[path].(map[string]interface{})[method].(map[string]interface{})
+			if !ok {
+				t.Errorf("no operation %s %s", method, path)
+				continue
+			}
+			where := method + " " + path
+			if op3["operationId"] != op2["operationId"] {
+				t.Errorf("%s: operationId %v, want %v", where, op3["operationId"], op2["operationId"])
+			}
+			var params []interface{}
+			for _, p := range op2["parameters"].([]interface{}) {
+				p := p.(map[string]interface{})
+				if p["in"] == "body" {
+					body, _ := op3["requestBody"].(map[string]interface{})
+					want := map[string]interface{}{"application/json": map[string]interface{}{"schema": openAPIRefs(p["schema"])}}
+					if !reflect.DeepEqual(body["content"], plain(t, want)) || body["required"] != p["required"] {
+						t.Errorf("%s: requestBody %v, for the body %v", where, body, p)
+					}
+					continue
+				}
+				want := map[string]interface{}{"name": p["name"], "in": p["in"], "schema": map[string]interface{}{"type": p["type"]}}
+				if p["format"] != nil {
+					want["schema"].(map[string]interface{})["format"] = p["format"]
+				}
+				if p["required"] == true {
+					want["required"] = true
+				}
+				params = append(params, want)
+			}
+			var wantParams interface{}
+			if len(params) > 0 {
+				wantParams = plain(t, params)
+			}
+			if !reflect.DeepEqual(op3["parameters"], wantParams) {
+				t.Errorf("%s: parameters %v, want %v", where, op3["parameters"], params)
+			}
+			responses := op3["responses"].(map[string]interface{})
+			for code, r := range op2["responses"].(map[string]interface{}) {
+				r2 := r.(map[string]interface{})
+				want := map[string]interface{}{"description": r2["description"]}
+				if r2["schema"] != nil {
+					want["content"] = map[string]interface{}{"application/json": map[string]interface{}{"schema": openAPIRefs(r2["schema"])}}
+				}
+				if !reflect.DeepEqual(responses[code], plain(t, want)) {
+					t.Errorf("%s: response %s is %v, want %v", where, code, responses[code], want)
+				}
+			}
+			if len(responses) != len(op2["responses"].(map[string]interface{})) {
+				t.Errorf("%s: %d responses, want %d", where, len(responses), len(op2["responses"].(map[string]interface{})))
+			}
+		}
+	}
+	if strings.Contains(compactJSON(t, doc), "#/definitions/") {
+		t.Errorf("a $ref still points into definitions")
+	}
+}
+
+// compactJSON returns the value as compact JSON.
+func compactJSON(t *testing.T, v interface{}) string {
+	t.Helper()
+	j, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(j)
+}
+
+// TestOpenAPIServers checks the servers made from the host, schemes and base
+// path of a Swagger 2.0 document.
+func TestOpenAPIServers(t *testing.T) {
+	tests := []struct {
+		host     string
+		schemes  []string
+		basePath string
+		want     []string
+	}{
+		{"", nil, "", nil},
+		{"", nil, "/api", []string{"/api"}},
+		{"", []string{"https"}, "/api", []string{"/api"}},
+		{"example.com", nil, "/api", []string{"//example.com/api"}},
+		{"example.com", []string{"https"}, "/api", []string{"https://example.com/api"}},
+		{"example.com:8080", []string{"http", "https"}, "", []string{"http://example.com:8080", "https://example.com:8080"}},
+	}
+	for _, tt := range tests {
+		swag := &swagger.Doc{Host: tt.host, Schemes: tt.schemes, BasePath: tt.basePath}
+		var got []string
+		for _, s := range openAPIServers(swag) {
+			got = append(got, s.(map[string]interface{})["url"].(string))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("host %q, schemes %v, basePath %q: servers %v, want %v", tt.host, tt.schemes, tt.basePath, got, tt.want)
+		}
+	}
+}
+
+// TestOpenAPISecurityScheme checks the conversion of security definitions.
+func TestOpenAPISecurityScheme(t *testing.T) {
+	tests := []struct {
+		def  swagger.SecurityDef
+		want map[string]interface{}
+	}{
+		{swagger.SecurityDef{Type: "basic"}, map[string]interface{}{"type": "http", "scheme": "basic"}},
+		{swagger.SecurityDef{Type: "apiKey", In: "query", Name: "key"}, map[string]interface{}{"type": "apiKey", "in": "query", "name": "key"}},
+		{swagger.SecurityDef{Type: "oauth2"}, map[string]interface{}{"type": "oauth2"}},
+	}
+	for _, tt := range tests {
+		def := tt.def
+		if got := openAPISecurityScheme(&def); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: got %v, want %v", tt.def, got, tt.want)
+		}
+	}
+}
+
+// TestArrayParameters checks that array query parameters have items, rather
+// than a schema, in 2.0, and a style for their collection format in 3.0.
+func TestArrayParameters(t *testing.T) {
+	schema, err := rdl.ParseRDLFile("testdata/search.rdl", false, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	swag, err := genSwagger(schema, "", "2.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want2 := `[{"name":"tag","in":"query","type":"array","items":{"type":"string"},"collectionFormat":"csv","required":true},` +
+		`{"name":"id[]","in":"query","type":"array","items":{"format":"int32","type":"integer"},"collectionFormat":"multi"}]`
+	if got := compactJSON(t, swag.Paths["/search"].Get.Parameters); got != want2 {
+		t.Errorf("2.0 parameters are %s, want %s", got, want2)
+	}
+	want3 := `[{"explode":false,"in":"query","name":"tag","required":true,"schema":{"items":{"type":"string"},"type":"array"},"style":"form"},` +
+		`{"explode":true,"in":"query","name":"id[]","schema":{"items":{"format":"int32","type":"integer"},"type":"array"},"style":"form"}]`
+	if got := compactJSON(t, openAPIOperation(swag.Paths["/search"].Get)["parameters"]); got != want3 {
+		t.Errorf("3.0 parameters are %s, want %s", got, want3)
+	}
+}
+
+// TestOpenAPIOperation checks the conversion of the operationId, collection
+// formats and formData parameters of an operation.
+func TestOpenAPIOperation(t *testing.T) {
+	tags := &swagger.Parameter{Name: "tags", In: "query", Type: "array", Items: swagger.Type{"type": "string"}}
+	tests := []struct {
+		op   swagger.Operation
+		want string
+	}{
+		{
+			swagger.Operation{},
+			`{"responses":{}}`,
+		},
+		{
+			swagger.Operation{OperationID: "getItems"},
+			`{"operationId":"getItems","responses":{}}`,
+		},
+		{
+			swagger.Operation{Parameters: []*swagger.Parameter{
+				{Name: "a", In: "query", Type: "array", Items: swagger.Type{"type": "string"}, CollectionFormat: "ssv"},
+				{Name: "b", In: "query", Type: "array", Items: swagger.Type{"type": "string"}, CollectionFormat: "pipes"},
+				{Name: "c", In: "path", Type: "array", Items: swagger.Type{"type": "string"}, CollectionFormat: "csv", Required: true},
+				tags,
+			}},
+			`{"parameters":[` +
+				`{"explode":false,"in":"query","name":"a","schema":{"items":{"type":"string"},"type":"array"},"style":"spaceDelimited"},` +
+				`{"explode":false,"in":"query","name":"b","schema":{"items":{"type":"string"},"type":"array"},"style":"pipeDelimited"},` +
+				`{"in":"path","name":"c","required":true,"schema":{"items":{"type":"string"},"type":"array"}},` +
+				`{"explode":false,"in":"query","name":"tags","schema":{"items":{"type":"string"},"type":"array"},"style":"form"}],"responses":{}}`,
+		},
+		{
+			swagger.Operation{Parameters: []*swagger.Parameter{
+				{Name: "name", In: "formData", Type: "string", Required: true, Description: "who"},
+				{Name: "age", In: "formData", Type: "integer", Format: "int32"},
+				tags,
+			}},
+			`{"parameters":[{"explode":false,"in":"query","name":"tags","schema":{"items":{"type":"string"},"type":"array"},"style":"form"}],` +
+				`"requestBody":{"content":{"application/x-www-form-urlencoded":{"schema":{"properties":{"age":{"format":"int32","type":"integer"},` +
+				`"name":{"description":"who","type":"string"}},"required":["name"],"type":"object"}}},"required":true},"responses":{}}`,
+		},
+		{
+			swagger.Operation{Parameters: []*swagger.Parameter{
+				{Name: "file", In: "formData", Type: "file"},
+			}},
+			`{"requestBody":{"content":{"multipart/form-data":{"schema":{"properties":{"file":{"format":"binary","type":"string"}},"type":"object"}}}},"responses":{}}`,
+		},
+		{
+			swagger.Operation{Consumes: []string{"multipart/form-data"}, Parameters: []*swagger.Parameter{
+				{Name: "name", In: "formData", Type: "string"},
+			}},
+			`{"requestBody":{"content":{"multipart/form-data":{"schema":{"properties":{"name":{"type":"string"}},"type":"object"}}}},"responses":{}}`,
+		},
+	}
+	for _, tt := range tests {
+		op := tt.op
+		if got := compactJSON(t, openAPIOperation(&op)); got != tt.want {
+			t.Errorf("%s: got %s, want %s", compactJSON(t, tt.op), got, tt.want)
+		}
+	}
+}
+
+// TestSwaggerPaths checks that a 2.0 document without resources still has the
+// paths it requires.
+func TestSwaggerPaths(t *testing.T) {
+	swag, err := genSwagger(&rdl.Schema{Name: "lib"}, "", "2.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := compactJSON(t, newSwaggerDoc(swag)); !strings.Contains(got, `"paths":{}`) {
+		t.Errorf("document is %s, want empty paths", got)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ardielle/ardielle-go/rdl"
+	"github.com/ardielle/ardielle-tools/rdl-plugins/swagger/importer"
+)
+
+// swaggerDoc returns a swagger document with the given paths and definitions,
+// each a JSON object.
+func swaggerDoc(paths string, definitions string) string {
+	return `{"swagger": "2.0", "info": {"title": "test", "version": "1"}, "paths": ` + paths + `, "definitions": ` + definitions + `}`
+}
+
+// convert converts the document with the default options, failing the test
+// on an error.
+func convert(t *testing.T, doc string) *rdl.Schema {
+	t.Helper()
+	schema, err := importer.Convert("test", []byte(doc), importer.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schema
+}
+
+// TestListOperations checks the table written by -list-operations, sorted by
+// path and method, with the made up names of operations without an
+// operationId.
+func TestListOperations(t *testing.T) {
+	paths := `{
+		"/pets": {"get": {"operationId": "listPets", "responses": {"200": {"description": "ok", "schema": {"type": "array", "items": {"$ref": "#/definitions/Pet"}}}}}, "post": {"parameters": [{"name": "body", "in": "body", "schema": {"$ref": "#/definitions/Pet"}}], "responses": {"201": {"description": "ok", "schema": {"$ref": "#/definitions/Pet"}}}}},
+		"/pets/{id}": {"delete": {"parameters": [{"name": "id", "in": "path", "required": true, "type": "string"}], "responses": {"204": {"description": "ok"}}}},
+		"/a": {"get": {"operationId": "getA", "responses": {"200": {"description": "ok", "schema": {"type": "string"}}}}}
+	}`
+	schema := convert(t, swaggerDoc(paths, `{"Pet": {"type": "object", "properties": {"id": {"type": "string"}}}}`))
+	var b strings.Builder
+	listOperations(&b, schema)
+	want := "GET    /a         -> String (getA)\n" +
+		"GET    /pets      -> Array  (listPets)\n" +
+		"POST   /pets      -> Pet    (postPets)\n" +
+		"DELETE /pets/{id} -> Any    (deletePetsById)\n"
+	if b.String() != want {
+		t.Errorf("listing is\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+// TestWarningsFormat checks the lines written for warnings and errors in each
+// warnings format, a multiline message staying on one line as JSON.
+func TestWarningsFormat(t *testing.T) {
+	tests := []struct {
+		format   string
+		level    string
+		location string
+		text     string
+		want     string
+	}{
+		{"text", "warning", "definitions.T", "no items", "WARNING: definitions.T: no items\n"},
+		{"text", "error", "", "cannot read", "*** cannot read\n"},
+		{"json", "warning", "definitions.T", "no items", `{"level":"warning","message":"no items","location":"definitions.T"}` + "\n"},
+		{"json", "error", "", "two\nlines", `{"level":"error","message":"two\nlines"}` + "\n"},
+	}
+	defer func(format string, stderr *os.File) {
+		warningsFormat = format
+		os.Stderr = stderr
+	}(warningsFormat, os.Stderr)
+	for _, tt := range tests {
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		os.Stderr = w
+		warningsFormat = tt.format
+		logMessage(tt.level, tt.location, tt.text)
+		w.Close()
+		got, _ := ioutil.ReadAll(r)
+		r.Close()
+		if string(got) != tt.want {
+			t.Errorf("%s %s %q: wrote %q, want %q", tt.format, tt.level, tt.text, got, tt.want)
+		}
+	}
+}
+
+// TestReadInput checks the document and name read from a file, and from stdin
+// for "-".
+func TestReadInput(t *testing.T) {
+	doc := swaggerDoc(`{}`, `{"T": {"type": "string"}}`)
+	dir, err := ioutil.TempDir("", "rdl-import-swagger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "pets.v2.json")
+	if err := ioutil.WriteFile(file, []byte(doc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(stdin *os.File) { os.Stdin = stdin }(os.Stdin)
+	os.Stdin = r
+	go func() {
+		w.Write([]byte(doc))
+		w.Close()
+	}()
+	tests := []struct {
+		path string
+		name string
+	}{
+		{file, "pets.v2"},
+		{"-", "Api"},
+	}
+	for _, tt := range tests {
+		name, data, err := readInput(tt.path)
+		if err != nil {
+			t.Errorf("%s: %v", tt.path, err)
+			continue
+		}
+		if name != tt.name || string(data) != doc {
+			t.Errorf("%s: read %q named %q, want %q named %q", tt.path, data, name, doc, tt.name)
+		}
+		if schema := convert(t, string(data)); len(schema.Types) != 1 {
+			t.Errorf("%s: %d types, want 1", tt.path, len(schema.Types))
+		}
+	}
+	r.Close()
+	if _, _, err := readInput(filepath.Join(dir, "missing.json")); err == nil {
+		t.Errorf("no error for a missing file")
+	}
+}
+
+// TestAnnotationFlag checks that each -annotation name=value is collected,
+// the value being everything after the first =.
+func TestAnnotationFlag(t *testing.T) {
+	annotations := make(map[string]string)
+	flag := annotationFlag(annotations)
+	for _, s := range []string{"x_go_package=foo/bar", "x_empty=", "x_eq=a=b"} {
+		if err := flag.Set(s); err != nil {
+			t.Errorf("%s: %v", s, err)
+		}
+	}
+	want := map[string]string{"x_go_package": "foo/bar", "x_empty": "", "x_eq": "a=b"}
+	for k, v := range want {
+		if got, ok := annotations[k]; !ok || got != v {
+			t.Errorf("annotation %s is %q, want %q", k, got, v)
+		}
+	}
+	for _, s := range []string{"x_go_package", "=foo"} {
+		if err := flag.Set(s); err == nil {
+			t.Errorf("%s: no error", s)
+		}
+	}
+}
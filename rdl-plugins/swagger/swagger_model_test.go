@@ -0,0 +1,31 @@
+package swagger
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestDocOptionalFields checks that a document needs only swagger and info,
+// so that a library of definitions, without paths or a base path, loads.
+func TestDocOptionalFields(t *testing.T) {
+	tests := []struct {
+		doc string
+		err string
+	}{
+		{`{"swagger": "2.0", "info": {"title": "t", "version": "1"}, "paths": {}, "basePath": "/v1", "definitions": {}}`, ""},
+		{`{"swagger": "2.0", "info": {"title": "t", "version": "1"}, "definitions": {"T": {"type": "string"}}}`, ""},
+		{`{"swagger": "2.0", "info": {"title": "t", "version": "1"}}`, ""},
+		{`{"info": {"title": "t", "version": "1"}}`, "Doc.swagger is missing"},
+	}
+	for _, tt := range tests {
+		var doc Doc
+		err := json.Unmarshal([]byte(tt.doc), &doc)
+		if tt.err == "" && err != nil {
+			t.Errorf("%s: %v", tt.doc, err)
+		}
+		if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("%s: got error %v, want one containing %q", tt.doc, err, tt.err)
+		}
+	}
+}